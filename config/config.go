@@ -2,49 +2,393 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrInvalidListenAddr is returned when the listen address is not a valid host:port.
+	ErrInvalidListenAddr = errors.New("invalid listen address")
+	// ErrInvalidListenNetwork is returned when the listen network is not tcp, tcp4 or tcp6.
+	ErrInvalidListenNetwork = errors.New("invalid listen network")
+	// ErrInvalidTrustedProxy is returned when a trusted proxy is neither an IP nor a CIDR.
+	ErrInvalidTrustedProxy = errors.New("invalid trusted proxy")
+	// ErrInvalidGinMode is returned when the gin mode is not debug, release or test.
+	ErrInvalidGinMode = errors.New("invalid gin mode")
+	// ErrInvalidTimeoutPolicy is returned when the health check timeout policy is not panic or restart.
+	ErrInvalidTimeoutPolicy = errors.New("invalid health check timeout policy")
+	// ErrInvalidFailureDetail is returned when the health check failure detail is not none, name or error.
+	ErrInvalidFailureDetail = errors.New("invalid health check failure detail")
+	// ErrInvalidTLS is returned when the TLS files are configured inconsistently.
+	ErrInvalidTLS = errors.New("invalid tls configuration")
+	// ErrInvalidHealthCheckAlias is returned when a health check path alias is not a plain absolute path.
+	ErrInvalidHealthCheckAlias = errors.New("invalid health check path alias")
+)
+
+// HealthCheckTimeoutPolicy is what happens when EnableHealthCheck() isn't called within
+// HealthCheckEnableTimeout.
+type HealthCheckTimeoutPolicy string
+
+const (
+	// HealthCheckTimeoutPolicyPanic panics when the timeout expires. This is the default.
+	HealthCheckTimeoutPolicyPanic HealthCheckTimeoutPolicy = "panic"
+	// HealthCheckTimeoutPolicyRestart re-arms the timeout once, giving a second grace period,
+	// and panics only if that also expires. Useful for services with widely varying init times.
+	HealthCheckTimeoutPolicyRestart HealthCheckTimeoutPolicy = "restart"
 )
 
 // TelemetryServerConfig contains HTTP server configuration.
 type TelemetryServerConfig struct {
+	// ListenAddress may list several comma-separated addresses, e.g. ":28080,:28081", which are
+	// tried in order until one binds. A bare port such as "28081" means ":28081".
 	ListenAddress            string        `envconfig:"INTERNAL_SERVER_LISTEN_ADDR" default:":28080"`
 	HealthCheckEnableTimeout time.Duration `envconfig:"INTERNAL_SERVER_WAIT_ENABLE_HEALTH_CHECK_DURATION" default:"1m"`
 	HealthCheckPollInterval  time.Duration `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_POLL_INTERVAL" default:"15s"`
+	// HealthCheckTimeoutPolicy is "panic" or "restart". Empty is treated as "panic".
+	HealthCheckTimeoutPolicy HealthCheckTimeoutPolicy `envconfig:"INTERNAL_SERVER_WAIT_ENABLE_HEALTH_CHECK_POLICY" default:"panic"`
+	// HealthCheckTimeout bounds a whole /_hc evaluation, on top of any per-check timeouts. Zero means no bound.
+	HealthCheckTimeout time.Duration `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_TIMEOUT" default:"0s"`
+	// HealthCheckSlowThreshold logs a warning for each check slower than this. Zero disables it.
+	HealthCheckSlowThreshold time.Duration `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_SLOW_THRESHOLD" default:"500ms"`
+	// HealthCheckCacheTTL, when positive, reuses the last /_hc result for this long instead of
+	// running the checks on every probe. HealthCheckCacheJitter adds a random extra of up to
+	// its value to each result's lifetime, so a fleet doesn't probe a shared downstream in lockstep.
+	HealthCheckCacheTTL    time.Duration `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_CACHE_TTL" default:"0s"`
+	HealthCheckCacheJitter time.Duration `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_CACHE_JITTER" default:"0s"`
+	// HealthCheckConcurrency is how many checks run at once across all probes, on workers
+	// reused between them. 1 runs the checks of each evaluation one after another.
+	HealthCheckConcurrency int `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_CONCURRENCY" default:"1"`
+	// HealthCheckPathAliases are extra paths, e.g. "/healthz" and "/health", that serve the
+	// same handler as /_hc for clients hardcoded to another path.
+	HealthCheckPathAliases []string `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_PATH_ALIASES"`
+	// ReadinessFile, when set, registers a "readiness-file" check that passes only while
+	// this file exists, so deployment tooling can gate readiness by writing it.
+	ReadinessFile string `envconfig:"INTERNAL_SERVER_READINESS_FILE"`
+	// ReadinessMinUptime, when positive, registers a "min-uptime" check that fails until the
+	// server has been running this long, as a warm-up gate on top of EnableHealthCheck().
+	ReadinessMinUptime time.Duration `envconfig:"INTERNAL_SERVER_READINESS_MIN_UPTIME" default:"0s"`
+	// HealthCheckFailureDetail is what the plain-text unhealthy body includes about the first
+	// failing check: "none" (fixed "unhealthy"), "name" or "error". Empty is treated as "none".
+	HealthCheckFailureDetail string `envconfig:"INTERNAL_SERVER_HEALTH_CHECK_FAILURE_DETAIL" default:"none"`
+	// ListenNetwork selects the address family of every listener, including the health and gRPC
+	// health ones: "tcp" (dual-stack where supported), "tcp4" or "tcp6". Empty is treated as "tcp".
+	ListenNetwork string `envconfig:"INTERNAL_SERVER_LISTEN_NETWORK" default:"tcp"`
+	// TrustedProxies are the IPs or CIDRs whose forwarding headers are used for the client IP.
+	// Empty trusts no proxies, so the client IP is always the remote address.
+	TrustedProxies []string `envconfig:"INTERNAL_SERVER_TRUSTED_PROXIES"`
+	// GinMode is the gin mode for the internal router: "release", "debug" or "test".
+	// Empty is treated as "release". Note gin's mode is process-wide.
+	GinMode string `envconfig:"INTERNAL_SERVER_GIN_MODE" default:"release"`
+	// MaxHeaderBytes limits request header size on the internal port. Zero uses the
+	// net/http default of 1 MiB.
+	MaxHeaderBytes int `envconfig:"INTERNAL_SERVER_MAX_HEADER_BYTES" default:"0"`
+	// FallbackToEphemeralPort retries on an OS-assigned port if ListenAddress is already in use
+	FallbackToEphemeralPort bool `envconfig:"INTERNAL_SERVER_FALLBACK_TO_EPHEMERAL_PORT" default:"false"`
+	// DrainGracePeriod is how long the wire cleanup reports draining before stopping,
+	// giving load balancers time to notice. Zero stops immediately.
+	DrainGracePeriod time.Duration `envconfig:"INTERNAL_SERVER_DRAIN_GRACE" default:"0s"`
+	// ProfilingShutdownGrace is how long in-flight /debug/pprof/ requests, such as a CPU profile,
+	// may keep running once Stop starts before their connections are closed. Other requests
+	// are waited for until the stop context ends. Zero cuts profiles off immediately.
+	ProfilingShutdownGrace time.Duration `envconfig:"INTERNAL_SERVER_PROFILING_SHUTDOWN_GRACE" default:"0s"`
+	// HealthRouteTimeout and MetricsRouteTimeout bound requests to the health check routes
+	// (except the /_hc/watch stream) and /metrics; slower requests get 503 "timeout".
+	// ProfilingRouteTimeout bounds /debug/pprof/ requests, ending longer profiles early.
+	// Zero means no bound.
+	HealthRouteTimeout    time.Duration `envconfig:"INTERNAL_SERVER_HEALTH_ROUTE_TIMEOUT" default:"5s"`
+	MetricsRouteTimeout   time.Duration `envconfig:"INTERNAL_SERVER_METRICS_ROUTE_TIMEOUT" default:"30s"`
+	ProfilingRouteTimeout time.Duration `envconfig:"INTERNAL_SERVER_PROFILING_ROUTE_TIMEOUT" default:"0s"`
+	// MetricsDumpOnShutdownPath, when set, is where Stop writes the final /metrics output in
+	// the Prometheus text format, e.g. on a log-collected volume to keep a crashed pod's counters.
+	MetricsDumpOnShutdownPath string `envconfig:"INTERNAL_SERVER_METRICS_DUMP_ON_SHUTDOWN_PATH"`
+	// MetricsToken, when set, gates /metrics behind "Authorization: Bearer <token>".
+	// Health checks and the other routes stay open.
+	MetricsToken string `envconfig:"INTERNAL_SERVER_METRICS_TOKEN"`
+	// TLSCertFile and TLSKeyFile, when both set, serve the internal port over HTTPS.
+	TLSCertFile string `envconfig:"INTERNAL_SERVER_TLS_CERT_FILE"`
+	TLSKeyFile  string `envconfig:"INTERNAL_SERVER_TLS_KEY_FILE"`
+	// ClientCAFile, when set, requires every connection to the internal port to present a client
+	// certificate signed by one of these PEM CAs (mTLS). It needs TLSCertFile and TLSKeyFile.
+	ClientCAFile string `envconfig:"INTERNAL_SERVER_CLIENT_CA_FILE"`
+	// HealthListenAddress, when set, also serves only the health check routes over plain HTTP on
+	// this address, for probes such as the kubelet's that can't present a client certificate.
+	HealthListenAddress string `envconfig:"INTERNAL_SERVER_HEALTH_LISTEN_ADDR"`
+	// GRPCHealthListenAddress starts a grpc.health.v1 server on this address when set
+	GRPCHealthListenAddress string        `envconfig:"INTERNAL_SERVER_GRPC_HEALTH_LISTEN_ADDR"`
+	GRPCHealthWatchInterval time.Duration `envconfig:"INTERNAL_SERVER_GRPC_HEALTH_WATCH_INTERVAL" default:"5s"`
 }
 
 // MetricsConfig contains OpenTelemetry metrics configuration.
 type MetricsConfig struct {
 	// DefaultHistogramBoundaries are used for all histograms not matching a specific pattern
 	DefaultHistogramBoundaries []float64
-	// HistogramBoundariesByName maps metric name patterns to custom boundaries (e.g., "*_ns" for nanosecond metrics)
+	// DefaultHistogramPreset names a preset ("latency_ms", "size_bytes" or "duration_ns")
+	// that replaces DefaultHistogramBoundaries when set
+	DefaultHistogramPreset string `envconfig:"DEFAULT_HISTOGRAM_PRESET"`
+	// HistogramBoundariesByName maps metric name patterns to custom boundaries (e.g., "*_ns" for nanosecond metrics).
+	// Patterns match the instrument name, before the exporter adds unit or "_total" suffixes.
 	HistogramBoundariesByName         map[string][]float64
 	RegisterDefaultPrometheusRegistry bool `envconfig:"REGISTER_DEFAULT_PROMETHEUS_REGISTRY" default:"false"`
+	// SortHistogramBoundaries sorts and deduplicates boundaries instead of rejecting unordered ones
+	SortHistogramBoundaries bool `envconfig:"SORT_HISTOGRAM_BOUNDARIES" default:"false"`
+	// OmitDefaultHistogramView skips the catch-all view applying DefaultHistogramBoundaries, so
+	// histograms declaring ExplicitBucketBoundaries advice keep their own buckets. Histograms
+	// with neither advice nor a matching named pattern then use the OTel SDK default buckets.
+	OmitDefaultHistogramView bool `envconfig:"OMIT_DEFAULT_HISTOGRAM_VIEW" default:"false"`
+	// PreferHistogramAdvice applies DefaultHistogramBoundaries only to histograms without
+	// ExplicitBucketBoundaries advice, so the precedence is HistogramBoundariesByName, then
+	// instrument advice, then the defaults.
+	PreferHistogramAdvice bool `envconfig:"PREFER_HISTOGRAM_ADVICE" default:"false"`
+	// OmitCatchAllView skips the "*" view that streams every instrument with its default
+	// aggregation. The SDK does the same for instruments no view matches, so this only trims
+	// per-instrument view evaluation; it is ignored while RenameRules are set, since renaming
+	// unmatched instruments relies on it.
+	OmitCatchAllView bool `envconfig:"OMIT_CATCH_ALL_VIEW" default:"false"`
+	// ForwardOTelLogs replaces the OTel global logger with one forwarding its errors and
+	// warnings, such as conflicting duplicate instruments, to slog. It is off by default so
+	// a logger installed with otel.SetLogger is kept.
+	ForwardOTelLogs bool `envconfig:"FORWARD_OTEL_LOGS" default:"false"`
+	// BuildInfoMetricName is the name of the build info gauge, e.g. "myapp_build_info".
+	// Empty disables it.
+	BuildInfoMetricName string `envconfig:"BUILD_INFO_METRIC_NAME" default:"service_build_info"`
+	// CPUQuotaMetricName is the name of the gauge reporting the cgroup CPU quota in cores,
+	// for comparing with go_processor_limit. Empty disables it.
+	CPUQuotaMetricName string `envconfig:"CPU_QUOTA_METRIC_NAME" default:"process_cpu_quota_cores"`
+	// ExcludeMetricNames lists metric families (by their exposed name, e.g. "noisy_requests_total")
+	// that are dropped from the /metrics output. They are still registered and collected.
+	ExcludeMetricNames []string `envconfig:"EXCLUDE_METRIC_NAMES"`
+	// DisabledInstruments lists instrument names, e.g. "rpc.client.duration" or "noisy.*", that
+	// are dropped in the SDK and never aggregated or exported. Unlike ExcludeMetricNames they
+	// match the instrument name, before the exporter adds unit or "_total" suffixes.
+	DisabledInstruments []string `envconfig:"DISABLED_INSTRUMENTS"`
+	// ScrapeTimeout bounds how long one /metrics scrape may take; slower scrapes get 503
+	// instead of hanging. Zero means no limit.
+	ScrapeTimeout time.Duration `envconfig:"PROMETHEUS_SCRAPE_TIMEOUT" default:"10s"`
+	// MaxConcurrentScrapes limits how many /metrics scrapes are served at once; scrapes beyond
+	// it get 503 right away instead of gathering a large registry in parallel. Each tenant
+	// endpoint has its own limit. Zero means no limit.
+	MaxConcurrentScrapes int `envconfig:"PROMETHEUS_MAX_CONCURRENT_SCRAPES" default:"0"`
+	// NameValidationScheme controls how OTel metric and label names are translated:
+	// "legacy" escapes characters outside [a-zA-Z0-9_:] to underscores for older Prometheus
+	// servers, "utf8" keeps names as-is (e.g. "http.requests_total"). Empty uses the exporter default.
+	NameValidationScheme string `envconfig:"PROMETHEUS_METRICS_NAME_VALIDATION"`
+	// ExpositionFormat forces the /metrics format: "text" or "protobuf". Empty negotiates it
+	// from the scraper's Accept header, which gives Prometheus protobuf when it asks for it.
+	ExpositionFormat string `envconfig:"PROMETHEUS_EXPOSITION_FORMAT"`
+	// Registry is an optional externally-owned registry. When set, OTel metrics are registered
+	// into it and the /metrics endpoint serves it, instead of a fresh registry being created.
+	Registry *prometheus.Registry `ignored:"true"`
+	// RenameRules are applied in order to every instrument name and attribute key,
+	// e.g. to migrate metric names without touching instrumentation code. Reserved labels
+	// are never renamed, and renames that would clash or be invalid are skipped.
+	RenameRules []RenameRule `ignored:"true"`
+	// GlobalLabels are added to every series served by /metrics, remote write and Pushgateway.
+	// Provider.SetGlobalLabel changes them at runtime.
+	GlobalLabels map[string]string `envconfig:"METRICS_GLOBAL_LABELS"`
+	// GathererWrappers transform what /metrics, remote write and Pushgateway serve. Each wraps
+	// the result of the previous one, starting from the registry with ExcludeMetricNames,
+	// RenameRules and GlobalLabels already applied.
+	GathererWrappers []func(prometheus.Gatherer) prometheus.Gatherer `ignored:"true"`
+	// ServiceNameFallback is this provider's meter scope and Pushgateway job name when neither
+	// the resource nor OTEL_SERVICE_NAME provide a service name. Empty uses the package-wide
+	// metrics.ServiceNameFallback.
+	ServiceNameFallback string `ignored:"true"`
+	// RemoteWrite periodically pushes the gathered registry to a Prometheus remote-write endpoint
+	RemoteWrite RemoteWriteConfig `envconfig:"PROMETHEUS_REMOTE_WRITE"`
+	// Pushgateway pushes the gathered registry to a Pushgateway, for short-lived jobs
+	Pushgateway PushgatewayConfig `envconfig:"PROMETHEUS_PUSHGATEWAY"`
+	// OTLP periodically exports metrics to an OTLP/HTTP endpoint alongside /metrics
+	OTLP OTLPConfig `envconfig:"OTLP_METRICS"`
+}
+
+// OTLPConfig configures exporting metrics over OTLP/HTTP (e.g. to an OpenTelemetry
+// Collector). Export is disabled unless Endpoint is set.
+type OTLPConfig struct {
+	// Endpoint is the full metrics URL, e.g. "http://otel-collector:4318/v1/metrics"
+	Endpoint string            `envconfig:"ENDPOINT"`
+	Interval time.Duration     `envconfig:"INTERVAL" default:"30s"`
+	Timeout  time.Duration     `envconfig:"TIMEOUT" default:"10s"`
+	Headers  map[string]string `envconfig:"HEADERS"`
+	// Temporality is "cumulative" or "delta" for every instrument not in TemporalityByKind
+	// or TemporalityByInstrument
+	Temporality string `envconfig:"TEMPORALITY" default:"cumulative"`
+	// TemporalityByKind overrides Temporality per instrument kind, e.g. {"histogram": "delta"}.
+	// Kinds are counter, up_down_counter, histogram, gauge, observable_counter,
+	// observable_up_down_counter and observable_gauge.
+	TemporalityByKind map[string]string `envconfig:"TEMPORALITY_BY_KIND"`
+	// TemporalityByInstrument overrides both per instrument, keyed by the exported name
+	// (after RenameRules), e.g. {"http.server.request.duration": "delta"}. Each temporality
+	// used here adds an exporting reader, which aggregates every instrument again.
+	TemporalityByInstrument map[string]string `envconfig:"TEMPORALITY_BY_INSTRUMENT"`
+}
+
+// PushgatewayConfig configures pushing metrics to a Prometheus Pushgateway on shutdown
+// (Provider.Cleanup) and optionally periodically. Pushing is disabled unless URL is set.
+type PushgatewayConfig struct {
+	URL string `envconfig:"URL"`
+	// Job is the job label; defaults to the service name
+	Job string `envconfig:"JOB"`
+	// Grouping adds grouping labels, e.g. instance or shard
+	Grouping map[string]string `envconfig:"GROUPING"`
+	// Interval pushes periodically as well as on shutdown. Zero pushes only on shutdown.
+	Interval time.Duration `envconfig:"INTERVAL" default:"0s"`
+	Timeout  time.Duration `envconfig:"TIMEOUT" default:"10s"`
+}
+
+// RemoteWriteConfig configures pushing metrics via the Prometheus remote-write protocol
+// (e.g. to Mimir or Cortex). Remote write is disabled unless URL is set.
+type RemoteWriteConfig struct {
+	URL      string        `envconfig:"URL"`
+	Interval time.Duration `envconfig:"INTERVAL" default:"30s"`
+	Timeout  time.Duration `envconfig:"TIMEOUT" default:"10s"`
+	// Username and Password enable basic auth when Username is set
+	Username string `envconfig:"USERNAME"`
+	Password string `envconfig:"PASSWORD"`
+	// Headers are added to every request, e.g. X-Scope-OrgID for multi-tenant Mimir
+	Headers map[string]string `envconfig:"HEADERS"`
+}
+
+// RenameRule rewrites names matching the Match regular expression to Replace.
+// Replace may reference capture groups ($1, ${name}) as in regexp.Regexp.ReplaceAllString.
+type RenameRule struct {
+	Match   string
+	Replace string
 }
 
 // LoadServerConfig loads server configuration from environment variables.
 func LoadServerConfig() (TelemetryServerConfig, error) {
 	var config TelemetryServerConfig
-	err := envconfig.Process("", &config)
-	return config, err
+	if err := envconfig.Process("", &config); err != nil {
+		return config, err
+	}
+
+	return config, config.Validate()
+}
+
+// Validate checks the configuration for values that would fail at startup.
+func (c TelemetryServerConfig) Validate() error {
+	for _, address := range SplitListenAddresses(c.ListenAddress) {
+		if err := validateListenAddress(address); err != nil {
+			return err
+		}
+	}
+
+	switch c.ListenNetwork {
+	case "", "tcp", "tcp4", "tcp6":
+	default:
+		return fmt.Errorf("%w %q: must be tcp, tcp4 or tcp6", ErrInvalidListenNetwork, c.ListenNetwork)
+	}
+
+	switch c.GinMode {
+	case "", "release", "debug", "test":
+	default:
+		return fmt.Errorf("%w %q: must be release, debug or test", ErrInvalidGinMode, c.GinMode)
+	}
+
+	switch c.HealthCheckTimeoutPolicy {
+	case "", HealthCheckTimeoutPolicyPanic, HealthCheckTimeoutPolicyRestart:
+	default:
+		return fmt.Errorf(
+			"%w %q: must be panic or restart", ErrInvalidTimeoutPolicy, c.HealthCheckTimeoutPolicy,
+		)
+	}
+
+	switch c.HealthCheckFailureDetail {
+	case "", "none", "name", "error":
+	default:
+		return fmt.Errorf(
+			"%w %q: must be none, name or error", ErrInvalidFailureDetail, c.HealthCheckFailureDetail,
+		)
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if err := validateTrustedProxy(proxy); err != nil {
+			return err
+		}
+	}
+
+	for _, alias := range c.HealthCheckPathAliases {
+		if !strings.HasPrefix(alias, "/") || strings.ContainsAny(alias, ":*") {
+			return fmt.Errorf("%w %q: must start with / and contain no : or *", ErrInvalidHealthCheckAlias, alias)
+		}
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("%w: TLS cert and key files must be set together", ErrInvalidTLS)
+	}
+	if c.ClientCAFile != "" && c.TLSCertFile == "" {
+		return fmt.Errorf("%w: client CA file %q requires TLS cert and key files", ErrInvalidTLS, c.ClientCAFile)
+	}
+
+	if c.HealthListenAddress != "" {
+		if err := validateListenAddress(c.HealthListenAddress); err != nil {
+			return err
+		}
+	}
+
+	if c.GRPCHealthListenAddress != "" {
+		return validateListenAddress(c.GRPCHealthListenAddress)
+	}
+
+	return nil
+}
+
+func validateTrustedProxy(proxy string) error {
+	if net.ParseIP(proxy) != nil {
+		return nil
+	}
+
+	if _, _, err := net.ParseCIDR(proxy); err != nil {
+		return fmt.Errorf("%w %q: must be an IP or CIDR", ErrInvalidTrustedProxy, proxy)
+	}
+
+	return nil
+}
+
+// SplitListenAddresses splits a comma-separated ListenAddress into the addresses to try
+// in order, turning bare ports such as "28081" into ":28081".
+func SplitListenAddresses(addresses string) []string {
+	split := strings.Split(addresses, ",")
+	for i, address := range split {
+		address = strings.TrimSpace(address)
+		if _, err := strconv.Atoi(address); err == nil {
+			address = ":" + address
+		}
+		split[i] = address
+	}
+	return split
+}
+
+func validateListenAddress(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidListenAddr, address, err)
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum < 0 || portNum > 65535 {
+		return fmt.Errorf("%w %q: port must be between 0 and 65535", ErrInvalidListenAddr, address)
+	}
+
+	return nil
 }
 
 // DefaultMetricsConfig returns a metrics configuration with sensible histogram boundaries.
 // Millisecond metrics use 1-10000ms boundaries, nanosecond metrics use 1ns-10s boundaries.
 func DefaultMetricsConfig() MetricsConfig {
 	config := MetricsConfig{
-		DefaultHistogramBoundaries: []float64{
-			1, 5, 30, 50, 100, 200, 300, 500, 700, 1000,
-			1500, 2000, 2500, 3000, 5000, 7000, 9000, 10000,
-		},
+		DefaultHistogramBoundaries: LatencyMsBoundaries(),
 		HistogramBoundariesByName: map[string][]float64{
-			"*_ns": {
-				1, 10, 100, 1000, 10000, 100000, 1000000, 5000000,
-				30000000, 50000000, 100000000, 200000000, 300000000,
-				500000000, 700000000, 1000000000, 1500000000, 2000000000,
-				2500000000, 3000000000, 5000000000, 7000000000, 9000000000, 10000000000,
-			},
+			"*_ns": DurationNsBoundaries(),
+			// The internal server's response size histogram
+			"http_server_response_size_bytes": SizeBytesBoundaries(),
 		},
 	}
 
@@ -0,0 +1,50 @@
+package config
+
+// Names accepted by MetricsConfig.DefaultHistogramPreset.
+const (
+	HistogramPresetLatencyMs  = "latency_ms"
+	HistogramPresetSizeBytes  = "size_bytes"
+	HistogramPresetDurationNs = "duration_ns"
+)
+
+// LatencyMsBoundaries returns buckets for request latencies in milliseconds, from 1ms to 10s.
+// These are the library's default boundaries.
+func LatencyMsBoundaries() []float64 {
+	return []float64{
+		1, 5, 30, 50, 100, 200, 300, 500, 700, 1000,
+		1500, 2000, 2500, 3000, 5000, 7000, 9000, 10000,
+	}
+}
+
+// SizeBytesBoundaries returns buckets for payload sizes in bytes, from 64B to 1GiB in powers of 4.
+func SizeBytesBoundaries() []float64 {
+	return []float64{
+		64, 256, 1024, 4096, 16384, 65536, 262144,
+		1048576, 4194304, 16777216, 67108864, 268435456, 1073741824,
+	}
+}
+
+// DurationNsBoundaries returns buckets for durations in nanoseconds, from 1ns to 10s.
+// These are the library's default boundaries for "*_ns" metrics.
+func DurationNsBoundaries() []float64 {
+	return []float64{
+		1, 10, 100, 1000, 10000, 100000, 1000000, 5000000,
+		30000000, 50000000, 100000000, 200000000, 300000000,
+		500000000, 700000000, 1000000000, 1500000000, 2000000000,
+		2500000000, 3000000000, 5000000000, 7000000000, 9000000000, 10000000000,
+	}
+}
+
+// HistogramPresetBoundaries returns the boundaries of the named preset, or false if there is none.
+func HistogramPresetBoundaries(name string) ([]float64, bool) {
+	switch name {
+	case HistogramPresetLatencyMs:
+		return LatencyMsBoundaries(), true
+	case HistogramPresetSizeBytes:
+		return SizeBytesBoundaries(), true
+	case HistogramPresetDurationNs:
+		return DurationNsBoundaries(), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"maps"
+	"net/url"
+)
+
+// RedactedValue replaces secrets in Redacted configurations.
+const RedactedValue = "REDACTED"
+
+// Redacted returns a copy of the configuration that is safe to log or serve, with
+// MetricsToken and the TLS key path replaced by RedactedValue when set.
+func (c TelemetryServerConfig) Redacted() TelemetryServerConfig {
+	c.MetricsToken = redactString(c.MetricsToken)
+	c.TLSKeyFile = redactString(c.TLSKeyFile)
+	return c
+}
+
+// Redacted returns a copy of the configuration that is safe to log or serve: passwords,
+// header values (which typically carry API keys) and credentials embedded in URLs are
+// replaced by RedactedValue.
+func (c MetricsConfig) Redacted() MetricsConfig {
+	c.RemoteWrite.URL = redactURL(c.RemoteWrite.URL)
+	c.RemoteWrite.Password = redactString(c.RemoteWrite.Password)
+	c.RemoteWrite.Headers = redactValues(c.RemoteWrite.Headers)
+	c.Pushgateway.URL = redactURL(c.Pushgateway.URL)
+	c.OTLP.Endpoint = redactURL(c.OTLP.Endpoint)
+	c.OTLP.Headers = redactValues(c.OTLP.Headers)
+	return c
+}
+
+func redactString(value string) string {
+	if value == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+func redactValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	redacted := maps.Clone(values)
+	for key := range redacted {
+		redacted[key] = RedactedValue
+	}
+	return redacted
+}
+
+// redactURL masks the password in user info. Unparseable URLs are redacted entirely,
+// since they can't be checked for credentials.
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return RedactedValue
+	}
+
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), RedactedValue)
+	}
+	return parsed.String()
+}
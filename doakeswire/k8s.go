@@ -0,0 +1,40 @@
+package doakeswire
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// K8sResourceAttributesEnv opts ProvideResource into K8sDownwardAPIDetector when "true".
+const K8sResourceAttributesEnv = "OTEL_RESOURCE_K8S_DOWNWARD_API"
+
+// K8sDownwardAPIDetector is a resource.Detector reading the POD_NAME, POD_NAMESPACE and
+// NODE_NAME environment variables, as typically injected through the Kubernetes downward
+// API, into k8s.pod.name, k8s.namespace.name and k8s.node.name. Unset variables are
+// skipped. The attributes become target_info labels on /metrics.
+type K8sDownwardAPIDetector struct{}
+
+// Detect implements resource.Detector.
+func (K8sDownwardAPIDetector) Detect(context.Context) (*resource.Resource, error) {
+	variables := []struct {
+		env string
+		key attribute.Key
+	}{
+		{env: "POD_NAME", key: semconv.K8SPodNameKey},
+		{env: "POD_NAMESPACE", key: semconv.K8SNamespaceNameKey},
+		{env: "NODE_NAME", key: semconv.K8SNodeNameKey},
+	}
+
+	var attributes []attribute.KeyValue
+	for _, variable := range variables {
+		if value := os.Getenv(variable.env); value != "" {
+			attributes = append(attributes, variable.key.String(value))
+		}
+	}
+
+	return resource.NewSchemaless(attributes...), nil
+}
@@ -2,12 +2,17 @@
 package doakeswire
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
 
 	"github.com/domesama/doakes/config"
+	"github.com/domesama/doakes/metrics"
 	"github.com/domesama/doakes/server"
 	"github.com/google/wire"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -22,6 +27,7 @@ var TelemetrySet = wire.NewSet(
 	ProvideTelemetryServerConfig,
 
 	server.New,
+	wire.Bind(new(server.TelemetryServerAPI), new(*server.TelemetryServer)),
 )
 
 // TelemetrySetWithAutoStart creates a server that starts automatically.
@@ -33,6 +39,7 @@ var TelemetrySetWithAutoStart = wire.NewSet(
 	ProvideTelemetryServerConfig,
 
 	ProvideServer,
+	wire.Bind(new(server.TelemetryServerAPI), new(*server.TelemetryServer)),
 )
 
 // ProvideTelemetryServerConfig loads server configuration from environment variables.
@@ -46,7 +53,11 @@ func ProvideMetricsConfig() config.MetricsConfig {
 }
 
 // ProvideResource creates an OpenTelemetry resource from environment variables.
-// Reads OTEL_SERVICE_NAME and OTEL_SERVICE_VERSION.
+// Reads OTEL_SERVICE_NAME and OTEL_SERVICE_VERSION, and with OTEL_RESOURCE_K8S_DOWNWARD_API=true
+// also the Kubernetes pod, namespace and node (see K8sDownwardAPIDetector).
+//
+// Partial resources (e.g. a detector failed, or schema URLs conflict) are logged and
+// used as-is rather than failing construction.
 func ProvideResource() (*resource.Resource, error) {
 	attributes := make([]attribute.KeyValue, 0)
 
@@ -62,10 +73,29 @@ func ProvideResource() (*resource.Resource, error) {
 		attributes = append(attributes, semconv.ServiceVersionKey.String(serviceVersion))
 	}
 
-	return resource.New(
-		nil,
-		resource.WithAttributes(attributes...),
-	)
+	options := []resource.Option{resource.WithAttributes(attributes...)}
+
+	if value := os.Getenv(K8sResourceAttributesEnv); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", K8sResourceAttributesEnv, value, err)
+		}
+		if enabled {
+			options = append(options, resource.WithDetectors(K8sDownwardAPIDetector{}))
+		}
+	}
+
+	res, err := resource.New(context.Background(), options...)
+	if err != nil && res != nil && isPartialResourceError(err) {
+		slog.Warn("Using partial OpenTelemetry resource", "error", err)
+		return res, nil
+	}
+
+	return res, err
+}
+
+func isPartialResourceError(err error) bool {
+	return errors.Is(err, resource.ErrPartialResource) || errors.Is(err, resource.ErrSchemaURLConflict)
 }
 
 // ProvideServerOptions creates server options from the provided dependencies.
@@ -88,6 +118,8 @@ func ProvideServerOptions(
 // The server is started but health checks are NOT enabled.
 // Call srv.EnableHealthCheck() after your initialization is complete.
 //
+// The cleanup drains for INTERNAL_SERVER_DRAIN_GRACE before stopping (see TelemetryServer.DrainAndStop).
+//
 // Usage:
 //
 //	srv, cleanup, err := wire.ProvideServer()
@@ -112,7 +144,7 @@ func ProvideServer(opts server.Options) (*server.TelemetryServer, func(), error)
 	}
 
 	cleanup := func() {
-		_ = srv.Stop()
+		_ = srv.DrainAndStop(context.Background(), opts.TelemetryServerConfig.DrainGracePeriod)
 	}
 
 	return srv, cleanup, nil
@@ -130,15 +162,5 @@ func ProvideServer(opts server.Options) (*server.TelemetryServer, func(), error)
 //	meter := doakeswire.GetMeter()
 //	counter, _ := meter.Int64Counter("requests_total")
 func GetMeter() metric.Meter {
-	serviceName := getServiceNameFromEnv()
-	return otel.GetMeterProvider().Meter(serviceName)
-}
-
-// getServiceNameFromEnv reads the service name from OTEL_SERVICE_NAME environment variable.
-func getServiceNameFromEnv() string {
-	serviceName := os.Getenv("OTEL_SERVICE_NAME")
-	if serviceName == "" {
-		serviceName = "unknown-service"
-	}
-	return serviceName
+	return metrics.GetDefaultMeter()
 }
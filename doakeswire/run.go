@@ -0,0 +1,70 @@
+package doakeswire
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/domesama/doakes/server"
+)
+
+// RunWithAutoStart runs the whole telemetry server lifecycle for a main function:
+// it initializes and starts the server as InitializeTelemetryServerWithAutoStart does,
+// calls setup to register health checks, enables health checks, and blocks until
+// SIGINT or SIGTERM. It then drains for INTERNAL_SERVER_DRAIN_GRACE and stops with
+// server.ShutdownReasonSignal.
+//
+// If setup returns an error, the server stops with server.ShutdownReasonError and
+// the error is returned without enabling health checks.
+//
+// Usage:
+//
+//	func main() {
+//	    err := doakeswire.RunWithAutoStart(func(srv *server.TelemetryServer) error {
+//	        srv.RegisterHealthCheck("database", checkDB)
+//	        return startWorkers()
+//	    })
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func RunWithAutoStart(setup func(srv *server.TelemetryServer) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return run(ctx, server.ShutdownReasonSignal, setup)
+}
+
+// RunWithAutoStartContext is RunWithAutoStart, but runs until ctx is done instead of
+// waiting for a signal and stops with server.ShutdownReasonManual.
+func RunWithAutoStartContext(ctx context.Context, setup func(srv *server.TelemetryServer) error) error {
+	return run(ctx, server.ShutdownReasonManual, setup)
+}
+
+func run(ctx context.Context, reason string, setup func(srv *server.TelemetryServer) error) error {
+	srv, cleanup, err := InitializeTelemetryServerWithAutoStart()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := setup(srv); err != nil {
+		_ = srv.StopWithReason(server.ShutdownReasonError)
+		return err
+	}
+	srv.EnableHealthCheck()
+
+	<-ctx.Done()
+	slog.Info("Shutting down gracefully", "reason", reason)
+
+	if gracePeriod := srv.Config().DrainGracePeriod; gracePeriod > 0 {
+		srv.SetDraining(true)
+		slog.Info("Draining internal telemetry server", "grace_period", gracePeriod)
+		time.Sleep(gracePeriod)
+	}
+
+	return srv.StopWithReason(reason)
+}
@@ -0,0 +1,56 @@
+package doakeswire_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/domesama/doakes/doakeswire"
+	"github.com/domesama/doakes/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWithAutoStartContext(t *testing.T) {
+	t.Setenv("INTERNAL_SERVER_LISTEN_ADDR", "127.0.0.1:0")
+	t.Setenv("INTERNAL_SERVER_WAIT_ENABLE_HEALTH_CHECK_DURATION", "5s")
+	t.Setenv("INTERNAL_SERVER_DRAIN_GRACE", "300ms")
+
+	errSetup := errors.New("setup failed")
+	var failed *server.TelemetryServer
+	started := time.Now()
+	err := doakeswire.RunWithAutoStartContext(
+		context.Background(), func(srv *server.TelemetryServer) error {
+			failed = srv
+			return errSetup
+		},
+	)
+	assert.ErrorIs(t, err, errSetup)
+	assert.False(t, failed.IsRunning(), "should stop when setup fails")
+	assert.False(t, failed.IsHealthCheckEnabled())
+	assert.Less(t, time.Since(started), 300*time.Millisecond, "a failed setup should not drain")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	srvChan := make(chan *server.TelemetryServer, 1)
+	go func() {
+		done <- doakeswire.RunWithAutoStartContext(
+			ctx, func(srv *server.TelemetryServer) error {
+				srv.RegisterHealthCheck("worker", func() error { return nil })
+				srvChan <- srv
+				return nil
+			},
+		)
+	}()
+
+	srv := <-srvChan
+	assert.Eventually(t, srv.IsHealthCheckEnabled, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, srv.Healthy())
+
+	started = time.Now()
+	cancel()
+	assert.NoError(t, <-done)
+	assert.False(t, srv.IsRunning())
+	assert.GreaterOrEqual(t, time.Since(started), 300*time.Millisecond)
+	assert.Less(t, time.Since(started), 600*time.Millisecond, "the grace period should run once")
+}
@@ -16,6 +16,13 @@ func InitializeTelemetryServer() (*server.TelemetryServer, error) {
 	return nil, nil
 }
 
+// InitializeTelemetryServerAPI is InitializeTelemetryServer returning the server.TelemetryServerAPI
+// interface, so callers can swap in their own implementation in tests.
+func InitializeTelemetryServerAPI() (server.TelemetryServerAPI, error) {
+	wire.Build(TelemetrySet)
+	return nil, nil
+}
+
 // InitializeTelemetryServerWithAutoStart creates and starts a internal telemetry server using Wire.
 // Returns the server, a cleanup function, and an error.
 // The server is started but health checks are NOT enabled - call EnableHealthCheck() after setup.
@@ -33,6 +33,26 @@ func InitializeTelemetryServer() (*server.TelemetryServer, error) {
 	return telemetryServer, nil
 }
 
+// InitializeTelemetryServerAPI is InitializeTelemetryServer returning the server.TelemetryServerAPI
+// interface, so callers can swap in their own implementation in tests.
+func InitializeTelemetryServerAPI() (server.TelemetryServerAPI, error) {
+	resource, err := ProvideResource()
+	if err != nil {
+		return nil, err
+	}
+	metricsConfig := ProvideMetricsConfig()
+	telemetryServerConfig, err := ProvideTelemetryServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	options := ProvideServerOptions(resource, metricsConfig, telemetryServerConfig)
+	telemetryServer, err := server.New(options)
+	if err != nil {
+		return nil, err
+	}
+	return telemetryServer, nil
+}
+
 // InitializeTelemetryServerWithAutoStart creates and starts a internal telemetry server using Wire.
 // Returns the server, a cleanup function, and an error.
 // The server is started but health checks are NOT enabled - call EnableHealthCheck() after setup.
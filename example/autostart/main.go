@@ -5,37 +5,44 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/domesama/doakes/doakeswire"
+	"github.com/domesama/doakes/server"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 )
 
 func main() {
-	// Initialize server with Wire - auto-starts and returns cleanup function
-	srv, cleanup, err := doakeswire.InitializeTelemetryServerWithAutoStart()
+	// Initialize and start the server, run setup, enable health checks, then block until
+	// SIGINT/SIGTERM and shut down gracefully
+	err := doakeswire.RunWithAutoStart(setup)
 	if err != nil {
-		panic("Failed to initialize server")
+		slog.Error("Telemetry server failed", "error", err)
+		os.Exit(1)
 	}
-	defer cleanup()
 
+	slog.Info("Shut down gracefully")
+}
+
+func setup(srv *server.TelemetryServer) error {
 	// Register health checks
 	srv.RegisterHealthCheck("database", checkDatabase)
 	srv.RegisterHealthCheck("cache", checkCache)
 
-	// Enable health checks after initialization is complete
-	srv.EnableHealthCheck()
-
 	// Get meter scoped to the service name from OTEL_SERVICE_NAME
 	meter := doakeswire.GetMeter()
 
 	// Create some example metrics
-	counter, _ := meter.Int64Counter("example_requests_total")
+	counter, err := meter.Int64Counter("example_requests_total")
+	if err != nil {
+		return err
+	}
 	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("method", "GET")))
 
-	histogram, _ := meter.Int64Histogram("example_request_duration_ms")
+	histogram, err := meter.Int64Histogram("example_request_duration_ms")
+	if err != nil {
+		return err
+	}
 	histogram.Record(context.Background(), 150, metric.WithAttributes(attribute.String("endpoint", "/api")))
 
 	// Get the actual running port (useful when using :0 for dynamic port)
@@ -50,10 +57,8 @@ func main() {
 		"health_url", fmt.Sprintf("http://localhost:%d/_hc", port),
 	)
 
-	// Wait for shutdown signal
-	waitForShutdown()
-
-	slog.Info("Shutting down gracefully")
+	// Health checks are enabled once setup returns
+	return nil
 }
 
 func checkDatabase() error {
@@ -65,9 +70,3 @@ func checkCache() error {
 	// Your cache health check logic
 	return nil
 }
-
-func waitForShutdown() {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
-}
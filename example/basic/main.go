@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -14,10 +16,14 @@ import (
 func main() {
 	// Create resource with service name
 	res, err := resource.New(
-		nil,
+		context.Background(),
 		resource.WithAttributes(attribute.String(string(semconv.ServiceNameKey), "my-service")),
 	)
-	if err != nil {
+	switch {
+	case errors.Is(err, resource.ErrPartialResource), errors.Is(err, resource.ErrSchemaURLConflict):
+		// Detectors commonly produce non-fatal errors; the returned resource is still usable
+		slog.Warn("Using partial resource", "error", err)
+	case err != nil:
 		panic("Failed to create resource")
 	}
 
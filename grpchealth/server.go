@@ -0,0 +1,192 @@
+// Package grpchealth exposes a healthcheck.Handler over the standard gRPC health
+// checking protocol (grpc.health.v1.Health), so grpc_health_probe and Envoy gRPC
+// health checks can reuse the checks registered for the HTTP endpoint.
+package grpchealth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/domesama/doakes/healthcheck"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+// Server serves the gRPC health protocol backed by a healthcheck.Handler.
+//
+// Only the overall service ("") is known; it reports SERVING when the handler
+// is enabled and all checks pass, NOT_SERVING otherwise.
+type Server struct {
+	healthpb.UnimplementedHealthServer
+
+	handler       *healthcheck.Handler
+	watchInterval time.Duration
+	grpcServer    *grpc.Server
+	network       string
+
+	mutex    sync.RWMutex
+	listener net.Listener
+
+	// done is closed when stopping, ending Watch streams so GracefulStop doesn't wait on them
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewServer creates a gRPC health server for the given handler.
+// watchInterval controls how often Watch streams re-evaluate the checks;
+// zero uses a 5 second default.
+func NewServer(handler *healthcheck.Handler, watchInterval time.Duration) *Server {
+	if watchInterval <= 0 {
+		watchInterval = defaultWatchInterval
+	}
+
+	server := &Server{
+		handler:       handler,
+		watchInterval: watchInterval,
+		grpcServer:    grpc.NewServer(),
+		network:       "tcp",
+		done:          make(chan struct{}),
+	}
+	healthpb.RegisterHealthServer(server.grpcServer, server)
+
+	return server
+}
+
+// Check evaluates the registered checks once and returns the serving status.
+func (s *Server) Check(_ context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.GetService() != "" {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+
+	return &healthpb.HealthCheckResponse{Status: s.evaluate()}, nil
+}
+
+// Watch streams the serving status, sending an update whenever it changes.
+// Checks are re-evaluated every watch interval until the client disconnects
+// or the server stops, in which case NOT_SERVING is sent and the stream ends
+// with codes.Unavailable. As the health protocol requires, a stream for an
+// unknown service reports SERVICE_UNKNOWN and stays open rather than ending.
+func (s *Server) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	lastStatus := s.watchStatus(req.GetService())
+	if err := stream.Send(&healthpb.HealthCheckResponse{Status: lastStatus}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+
+		case <-s.done:
+			_ = stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+			return status.Error(codes.Unavailable, "health server stopping")
+
+		case <-ticker.C:
+			currentStatus := s.watchStatus(req.GetService())
+			if currentStatus == lastStatus {
+				continue
+			}
+
+			lastStatus = currentStatus
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: currentStatus}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SetNetwork sets the network passed to net.Listen by Start ("tcp", "tcp4" or "tcp6").
+// Empty means "tcp". Call it before Start.
+func (s *Server) SetNetwork(network string) {
+	if network == "" {
+		network = "tcp"
+	}
+	s.network = network
+}
+
+// Start binds the address and serves the gRPC health service in the background.
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen(s.network, address)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+
+	slog.Info("Starting gRPC health server", "address", listener.Addr().String())
+
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.Error("gRPC health server failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop gracefully stops the gRPC server, ending any Watch streams.
+func (s *Server) Stop() {
+	s.StopContext(context.Background())
+}
+
+// StopContext ends Watch streams and gracefully stops the gRPC server, waiting for
+// in-flight Check calls. Once ctx ends, it closes the remaining connections instead.
+func (s *Server) StopContext(ctx context.Context) {
+	s.stopOnce.Do(func() { close(s.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		<-stopped
+	}
+}
+
+// ActualAddress returns the address the server is listening on,
+// or an empty string if it hasn't started yet.
+func (s *Server) ActualAddress() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// watchStatus is the status a Watch stream for service reports; only the overall
+// service ("") is known.
+func (s *Server) watchStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	if service != "" {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	return s.evaluate()
+}
+
+func (s *Server) evaluate() healthpb.HealthCheckResponse_ServingStatus {
+	if err := s.handler.Evaluate(); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,176 @@
+package grpchealth_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/domesama/doakes/grpchealth"
+	"github.com/domesama/doakes/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func startServer(t *testing.T, handler *healthcheck.Handler) healthpb.HealthClient {
+	server := grpchealth.NewServer(handler, 20*time.Millisecond)
+	assert.NoError(t, server.Start("127.0.0.1:0"))
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(server.ActualAddress(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestServer_Check(t *testing.T) {
+	handler := healthcheck.NewHandler("test-service")
+
+	var failing atomic.Bool
+	handler.RegisterCheck(
+		"database", func() error {
+			if failing.Load() {
+				return errors.New("database down")
+			}
+			return nil
+		},
+	)
+
+	client := startServer(t, handler)
+	ctx := context.Background()
+
+	// Not enabled yet
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	handler.Enable()
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	failing.Store(true)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	assert.Equal(t, healthcheck.StatusUnhealthy, handler.Status(), "gRPC checks share the handler's state")
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_Watch(t *testing.T) {
+	handler := healthcheck.NewHandler("test-service")
+	handler.Enable()
+
+	var failing atomic.Bool
+	handler.RegisterCheck(
+		"database", func() error {
+			if failing.Load() {
+				return errors.New("database down")
+			}
+			return nil
+		},
+	)
+
+	client := startServer(t, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	failing.Store(true)
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestServer_WatchUnknownService(t *testing.T) {
+	handler := healthcheck.NewHandler("test-service")
+	handler.Enable()
+
+	client := startServer(t, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.NoError(t, err)
+
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, resp.GetStatus())
+
+	received := make(chan error, 1)
+	go func() {
+		_, err := stream.Recv()
+		received <- err
+	}()
+	select {
+	case err := <-received:
+		t.Fatalf("stream for an unknown service ended: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-received))
+}
+
+func TestServer_StopEndsWatch(t *testing.T) {
+	handler := healthcheck.NewHandler("test-service")
+	handler.Enable()
+
+	server := grpchealth.NewServer(handler, time.Hour)
+	assert.NoError(t, server.Start("127.0.0.1:0"))
+
+	conn, err := grpc.NewClient(server.ActualAddress(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	stopped := make(chan struct{})
+	go func() {
+		server.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop blocked on an open Watch stream")
+	}
+
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServer_SetNetwork(t *testing.T) {
+	server := grpchealth.NewServer(healthcheck.NewHandler("test-service"), 0)
+	server.SetNetwork("tcp6")
+	assert.Error(t, server.Start("127.0.0.1:0"), "an IPv4 address should not bind on tcp6")
+
+	server = grpchealth.NewServer(healthcheck.NewHandler("test-service"), 0)
+	server.SetNetwork("tcp4")
+	assert.NoError(t, server.Start("127.0.0.1:0"))
+	t.Cleanup(server.Stop)
+	assert.NotEmpty(t, server.ActualAddress())
+}
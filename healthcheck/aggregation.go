@@ -0,0 +1,67 @@
+package healthcheck
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Aggregation decides the aggregate status from individual check results.
+// Muted checks are not counted.
+type Aggregation struct {
+	name string
+	// requiredHealthy returns how many of total active checks must pass
+	requiredHealthy func(total int) int
+}
+
+var (
+	// AggregateAll is healthy only if every check passes. This is the default.
+	AggregateAll = Aggregation{
+		name:            "all",
+		requiredHealthy: func(total int) int { return total },
+	}
+
+	// AggregateAny is healthy if at least one check passes, e.g. for redundant backends.
+	// It is healthy when there are no checks, like AggregateAll.
+	AggregateAny = Aggregation{
+		name:            "any",
+		requiredHealthy: func(total int) int { return min(total, 1) },
+	}
+)
+
+// AggregateQuorum is healthy if at least n checks pass. It is unhealthy when fewer
+// than n checks are registered. Values of n below 1 are raised to 1.
+func AggregateQuorum(n int) Aggregation {
+	n = max(n, 1)
+	return Aggregation{
+		name:            fmt.Sprintf("quorum(%d)", n),
+		requiredHealthy: func(int) int { return n },
+	}
+}
+
+// String returns the aggregation name, e.g. "all" or "quorum(2)".
+func (a Aggregation) String() string {
+	if a.requiredHealthy == nil {
+		return AggregateAll.name
+	}
+	return a.name
+}
+
+// required returns how many of total active checks must pass. The zero Aggregation
+// behaves like AggregateAll.
+func (a Aggregation) required(total int) int {
+	if a.requiredHealthy == nil {
+		return AggregateAll.requiredHealthy(total)
+	}
+	return a.requiredHealthy(total)
+}
+
+// SetAggregation sets how individual check results combine into the aggregate status.
+// Individual failures are always reported per check regardless of the aggregation.
+func (h *Handler) SetAggregation(aggregation Aggregation) {
+	h.checksMutex.Lock()
+	defer h.checksMutex.Unlock()
+
+	h.aggregation = aggregation
+	h.invalidateCache()
+	slog.Info("Health check aggregation set", "aggregation", aggregation)
+}
@@ -0,0 +1,78 @@
+package healthcheck
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// evaluationCache holds the result of the last check run for reuse by later probes.
+type evaluationCache struct {
+	ttl     time.Duration
+	jitter  time.Duration
+	report  Report
+	err     error
+	expires time.Time
+	// generation is bumped whenever checks change, so a run that started before the
+	// change doesn't store its outdated result
+	generation uint64
+}
+
+// SetCache makes evaluations within ttl of the last check run reuse its result instead of
+// running the checks again, bounding the load probes put on downstream dependencies.
+// Each result is kept for ttl plus a random extra of up to jitter, so pods sharing a
+// downstream drift apart instead of checking it in lockstep. Timeouts are never cached,
+// and registering, expecting or muting checks or changing the aggregation drops the
+// cached result. Zero ttl (the default) disables caching.
+func (h *Handler) SetCache(ttl time.Duration, jitter time.Duration) {
+	h.cacheMutex.Lock()
+	defer h.cacheMutex.Unlock()
+
+	h.cache.ttl = ttl
+	h.cache.jitter = jitter
+	h.cache.expires = time.Time{}
+	h.cache.generation++
+}
+
+// invalidateCache drops the cached result, if any.
+func (h *Handler) invalidateCache() {
+	h.cacheMutex.Lock()
+	defer h.cacheMutex.Unlock()
+
+	h.cache.expires = time.Time{}
+	h.cache.generation++
+}
+
+// runAllChecksCached is runAllChecks, reusing the cached result while it is fresh.
+// The checks run without holding cacheMutex, so concurrent probes on an expired
+// cache may each run them once.
+func (h *Handler) runAllChecksCached() (Report, error) {
+	h.cacheMutex.Lock()
+	if h.cache.ttl <= 0 {
+		h.cacheMutex.Unlock()
+		return h.runAllChecks()
+	}
+	if time.Now().Before(h.cache.expires) {
+		report, err := h.cache.report.clone(), h.cache.err
+		h.cacheMutex.Unlock()
+		return report, err
+	}
+	generation := h.cache.generation
+	h.cacheMutex.Unlock()
+
+	report, err := h.runAllChecks()
+
+	h.cacheMutex.Lock()
+	defer h.cacheMutex.Unlock()
+
+	if h.cache.generation == generation && h.cache.ttl > 0 {
+		keepFor := h.cache.ttl
+		if h.cache.jitter > 0 {
+			keepFor += rand.N(h.cache.jitter)
+		}
+		h.cache.report = report.clone()
+		h.cache.err = err
+		h.cache.expires = time.Now().Add(keepFor)
+	}
+
+	return report, err
+}
@@ -0,0 +1,5 @@
+// Package checks provides reusable health check constructors for common dependencies.
+//
+// Each constructor returns a healthcheck.CheckFunction that can be passed directly
+// to TelemetryServer.RegisterHealthCheck or Handler.RegisterCheck.
+package checks
@@ -0,0 +1,92 @@
+package checks_test
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/domesama/doakes/healthcheck/checks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTCPDialCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+
+	check := checks.TCPDialCheck(addr, time.Second)
+	assert.NoError(t, check(), "should connect to a listening port")
+
+	assert.NoError(t, listener.Close())
+	assert.Error(t, check(), "should fail once the port is closed")
+}
+
+func TestFileExistsCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+
+	check := checks.FileExistsCheck(path)
+	assert.Error(t, check(), "should fail before the file is written")
+
+	assert.NoError(t, os.WriteFile(path, nil, 0o600))
+	assert.NoError(t, check(), "should pass once the file exists")
+
+	assert.NoError(t, os.Remove(path))
+	assert.Error(t, check(), "should fail again once the file is removed")
+}
+
+func TestHTTPGetCheck(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, _ *http.Request) {
+				writer.WriteHeader(status)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	check := checks.HTTPGetCheck(server.URL, time.Second)
+	assert.NoError(t, check(), "2xx should pass")
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, check(), "non-2xx should fail")
+}
+
+func TestHTTPGetCheck_Unreachable(t *testing.T) {
+	check := checks.HTTPGetCheck("http://127.0.0.1:1", 100*time.Millisecond)
+	assert.Error(t, check())
+}
+
+func TestDNSCheck(t *testing.T) {
+	assert.NoError(t, checks.DNSCheck("localhost")())
+	assert.Error(t, checks.DNSCheck("does-not-exist.invalid")())
+}
+
+func TestHTTPGetCheckWithClient(t *testing.T) {
+	server := httptest.NewTLSServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, _ *http.Request) {
+				writer.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	// The default client doesn't trust the test server's certificate
+	assert.Error(t, checks.HTTPGetCheckWithClient(nil, server.URL)())
+
+	assert.NoError(t, checks.HTTPGetCheckWithClient(server.Client(), server.URL)())
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, checks.DiskSpaceCheck(dir, 1)())
+	assert.ErrorIs(t, checks.DiskSpaceCheck(dir, math.MaxUint64)(), checks.ErrLowDiskSpace)
+	assert.Error(t, checks.DiskSpaceCheck(filepath.Join(dir, "missing"), 1)())
+}
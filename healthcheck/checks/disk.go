@@ -0,0 +1,30 @@
+package checks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/domesama/doakes/healthcheck"
+)
+
+// ErrLowDiskSpace is returned by DiskSpaceCheck when free space is below the threshold.
+var ErrLowDiskSpace = errors.New("low disk space")
+
+// DiskSpaceCheck returns a check that fails once the space available to unprivileged users
+// on the filesystem holding path drops below minFreeBytes, so readiness degrades before
+// writes start failing on a full disk. It is only supported on Linux, macOS, FreeBSD and
+// DragonFly BSD; elsewhere it always fails.
+func DiskSpaceCheck(path string, minFreeBytes uint64) healthcheck.CheckFunction {
+	return func() error {
+		free, err := availableBytes(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat filesystem of %s: %w", path, err)
+		}
+
+		if free < minFreeBytes {
+			return fmt.Errorf("%w on %s: %d bytes free, %d required", ErrLowDiskSpace, path, free, minFreeBytes)
+		}
+
+		return nil
+	}
+}
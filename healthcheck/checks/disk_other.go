@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly
+
+package checks
+
+import "errors"
+
+func availableBytes(string) (uint64, error) {
+	return 0, errors.New("disk space checks are not supported on this platform")
+}
@@ -0,0 +1,25 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/domesama/doakes/healthcheck"
+)
+
+// DNSCheck returns a check that passes if host resolves to at least one address.
+func DNSCheck(host string) healthcheck.CheckFunction {
+	return func() error {
+		addrs, err := net.DefaultResolver.LookupHost(context.Background(), host)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+
+		if len(addrs) == 0 {
+			return fmt.Errorf("no addresses found for %s", host)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,20 @@
+package checks
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/domesama/doakes/healthcheck"
+)
+
+// FileExistsCheck returns a check that passes while path exists, e.g. a marker file
+// written by an init container or deployment tooling to signal readiness.
+func FileExistsCheck(path string) healthcheck.CheckFunction {
+	return func() error {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("readiness file %s: %w", path, err)
+		}
+
+		return nil
+	}
+}
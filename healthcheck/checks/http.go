@@ -0,0 +1,49 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/domesama/doakes/healthcheck"
+)
+
+// HTTPGetCheck returns a check that passes if a GET request to url
+// completes within timeout with a 2xx status code.
+func HTTPGetCheck(url string, timeout time.Duration) healthcheck.CheckFunction {
+	return HTTPGetCheckWithClient(&http.Client{Timeout: timeout}, url)
+}
+
+// HTTPGetCheckWithClient is like HTTPGetCheck but uses the given client,
+// e.g. one configured with client certificates, a proxy, or a custom timeout.
+// The client's own timeout applies; a nil client falls back to http.DefaultClient.
+func HTTPGetCheckWithClient(client *http.Client, url string) healthcheck.CheckFunction {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func() error {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request for %s: %w", url, err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to GET %s: %w", url, err)
+		}
+
+		defer func() {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+		}
+
+		return nil
+	}
+}
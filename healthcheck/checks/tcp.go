@@ -0,0 +1,22 @@
+package checks
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/domesama/doakes/healthcheck"
+)
+
+// TCPDialCheck returns a check that passes if a TCP connection to addr
+// can be established within timeout. The connection is closed immediately.
+func TCPDialCheck(addr string, timeout time.Duration) healthcheck.CheckFunction {
+	return func() error {
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return fmt.Errorf("failed to dial %s: %w", addr, err)
+		}
+
+		return conn.Close()
+	}
+}
@@ -0,0 +1,88 @@
+package healthcheck
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CompositeError is returned by a CompositeCheck when any sub-check fails. Results
+// holds every sub-check's outcome in name order, healthy ones included.
+type CompositeError struct {
+	Name    string
+	Results []CheckResult
+	// errs holds the sub-check errors by index into Results, for ErrorSanitizer
+	errs []error
+}
+
+func (e *CompositeError) Error() string {
+	failures := make([]string, 0, len(e.Results))
+	for _, result := range e.Results {
+		if result.Status == StatusUnhealthy {
+			failures = append(failures, result.Name+": "+result.Error)
+		}
+	}
+
+	return e.Name + ": " + strings.Join(failures, "; ")
+}
+
+// CompositeCheck rolls subchecks up into one check that fails if any of them fails,
+// e.g. a "storage" check over its database, cache and bucket. Sub-checks run in name
+// order. When registered on a Handler, a failing composite reports every sub-result
+// under its entry's subchecks.
+func CompositeCheck(name string, subchecks map[string]CheckFunction) CheckFunction {
+	names := make([]string, 0, len(subchecks))
+	for subName := range subchecks {
+		names = append(names, subName)
+	}
+	sort.Strings(names)
+
+	return func() error {
+		results := make([]CheckResult, 0, len(names))
+		errs := make([]error, 0, len(names))
+		failed := false
+
+		for _, subName := range names {
+			result := CheckResult{Name: subName, Status: StatusHealthy}
+			err := subchecks[subName]()
+			if err != nil {
+				result.Status = StatusUnhealthy
+				result.Error = err.Error()
+				failed = true
+			}
+			results = append(results, result)
+			errs = append(errs, err)
+		}
+
+		if !failed {
+			return nil
+		}
+
+		return &CompositeError{Name: name, Results: results, errs: errs}
+	}
+}
+
+// sanitizedResults returns Results with each failure's error passed through sanitize.
+// Without the original errors, e.g. for a CompositeError built by hand, the reported
+// strings are sanitized instead.
+func (e *CompositeError) sanitizedResults(sanitize ErrorSanitizer) []CheckResult {
+	if sanitize == nil {
+		return e.Results
+	}
+
+	results := slices.Clone(e.Results)
+	for i, result := range results {
+		if result.Status != StatusUnhealthy {
+			continue
+		}
+
+		err := errors.New(result.Error)
+		if i < len(e.errs) && e.errs[i] != nil {
+			err = e.errs[i]
+		}
+		results[i].Error = sanitize(err)
+	}
+
+	return results
+}
@@ -0,0 +1,49 @@
+package healthcheck
+
+import "fmt"
+
+// FailureDetail controls how much the plain-text unhealthy response body reveals.
+type FailureDetail string
+
+const (
+	// FailureDetailNone responds with a fixed "unhealthy" body. This is the default.
+	FailureDetailNone FailureDetail = "none"
+	// FailureDetailName adds the first failing check's name, e.g. "unhealthy: database".
+	FailureDetailName FailureDetail = "name"
+	// FailureDetailError adds the first failing check's name and error,
+	// e.g. "unhealthy: database: connection refused".
+	FailureDetailError FailureDetail = "error"
+)
+
+// SetFailureDetail sets how much the plain-text unhealthy body reveals about the first
+// failing check. The JSON report (/_hc?all=true) always carries every check's error.
+func (h *Handler) SetFailureDetail(detail FailureDetail) {
+	h.enabledMutex.Lock()
+	defer h.enabledMutex.Unlock()
+
+	h.detail = detail
+}
+
+func (h *Handler) unhealthyBody(report Report) string {
+	h.enabledMutex.RLock()
+	detail := h.detail
+	h.enabledMutex.RUnlock()
+
+	if detail != FailureDetailName && detail != FailureDetailError {
+		return "unhealthy"
+	}
+
+	for _, result := range report.Checks {
+		if result.Status != StatusUnhealthy {
+			continue
+		}
+
+		if detail == FailureDetailError {
+			return fmt.Sprintf("unhealthy: %s: %s", result.Name, result.Error)
+		}
+		return "unhealthy: " + result.Name
+	}
+
+	// e.g. a quorum that can't be met even though no check failed
+	return "unhealthy"
+}
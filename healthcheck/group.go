@@ -0,0 +1,98 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Group is the probe a check belongs to.
+type Group string
+
+const (
+	// GroupReadiness checks decide whether the service should receive traffic. This is the default.
+	GroupReadiness Group = "readiness"
+	// GroupLiveness checks decide whether the process is alive and should keep running.
+	// Readiness also requires them, since a service that isn't alive isn't ready either.
+	GroupLiveness Group = "liveness"
+)
+
+// GroupsReport is a snapshot of both probes from one evaluation.
+type GroupsReport struct {
+	Status    Status `json:"status"`
+	Liveness  Report `json:"liveness"`
+	Readiness Report `json:"readiness"`
+}
+
+// EvaluateLiveness runs only the liveness checks and returns their report along with
+// the first failure, if any. Unlike Evaluate, it runs before Enable and while draining,
+// and does not update the aggregate status.
+func (h *Handler) EvaluateLiveness() (Report, error) {
+	report, err := h.runChecksWithTimeout(
+		func() (Report, error) {
+			return h.runChecks(GroupLiveness, AggregateAll)
+		},
+	)
+	if errors.Is(err, ErrTimeout) {
+		return errorReport(err), err
+	}
+
+	return report, err
+}
+
+// EvaluateGroups runs liveness and readiness once and returns both results. It is
+// healthy only if both are. Readiness is evaluated exactly as Evaluate does.
+func (h *Handler) EvaluateGroups() GroupsReport {
+	groups := GroupsReport{Status: StatusHealthy}
+	groups.Liveness, _ = h.EvaluateLiveness()
+	groups.Readiness, _ = h.evaluateReadiness()
+
+	if groups.Liveness.Status != StatusHealthy || groups.Readiness.Status != StatusHealthy {
+		groups.Status = StatusUnhealthy
+	}
+
+	return groups
+}
+
+// LivenessHandler returns an http.Handler for the liveness probe. It returns 200 OK
+// while all liveness checks pass, including before Enable and while draining,
+// so the kubelet doesn't restart a service that is still starting or draining.
+func (h *Handler) LivenessHandler() http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			_, err := h.EvaluateLiveness()
+
+			switch {
+			case errors.Is(err, ErrTimeout):
+				h.writeResponse(writer, http.StatusServiceUnavailable, "timeout")
+			case err != nil:
+				h.writeResponse(writer, http.StatusServiceUnavailable, "unhealthy")
+			default:
+				h.writeResponse(writer, http.StatusOK, "ok")
+			}
+		},
+	)
+}
+
+// ReadinessHandler returns an http.Handler for the readiness probe. It responds like
+// /_hc: 503 until Enable, while draining, or while checks fail.
+func (h *Handler) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			h.ServeHTTP(writer, nil)
+		},
+	)
+}
+
+func (h *Handler) serveGroups(writer http.ResponseWriter) {
+	groups := h.EvaluateGroups()
+
+	statusCode := http.StatusOK
+	if groups.Status != StatusHealthy {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	_ = json.NewEncoder(writer).Encode(groups)
+}
@@ -2,56 +2,203 @@
 package healthcheck
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// ErrNotEnabled is returned by Evaluate when health checks have not been enabled yet.
+	ErrNotEnabled = errors.New("health check not enabled")
+	// ErrDraining is returned by Evaluate while the handler is draining.
+	ErrDraining = errors.New("health check draining")
+	// ErrTimeout is returned by Evaluate when the checks don't finish within the configured timeout.
+	ErrTimeout = errors.New("health check timed out")
+	// ErrExpectedCheckMissing is the error of a check declared with ExpectChecks but not registered yet.
+	ErrExpectedCheckMissing = errors.New("expected health check not registered")
 )
 
 // CheckFunction is a function that performs a health check.
 // Return nil if healthy, or an error if unhealthy.
 type CheckFunction func() error
 
+// Status is the aggregate result of the most recent health check evaluation.
+type Status string
+
+const (
+	// StatusUnknown means no evaluation has happened yet.
+	StatusUnknown Status = "unknown"
+	// StatusHealthy means enough checks passed on the last evaluation to satisfy the
+	// Aggregation (by default, all of them).
+	StatusHealthy Status = "healthy"
+	// StatusUnhealthy means the last evaluation did not satisfy the Aggregation.
+	StatusUnhealthy Status = "unhealthy"
+	// StatusMuted is reported for individual checks disabled via SetCheckEnabled.
+	// Muted checks are not run and never count toward failure.
+	StatusMuted Status = "muted"
+)
+
+// StatusChangeFunc is called when the aggregate status transitions.
+type StatusChangeFunc func(oldStatus, newStatus Status)
+
 // Handler manages registered health checks and serves HTTP health check requests.
 //
 // Health checks must be explicitly enabled via Enable() to prevent services
 // from passing health checks during initialization.
 type Handler struct {
 	serviceName string
-	checks      map[string]CheckFunction
+	checks      map[string]registeredCheck
+	// order holds the names in checks in evaluation order, see compareChecks
+	order       []string
+	aggregation Aggregation
+	// pool, when set, runs checks concurrently, see SetConcurrency
+	pool        *checkPool
 	checksMutex sync.RWMutex
 
 	enabledMutex sync.RWMutex
 	enabled      bool
+	draining     bool
+	timeout      time.Duration
+	slowAfter    time.Duration
+	detail       FailureDetail
+	sanitize     ErrorSanitizer
+	startedAt    time.Time
+	enableDelay  metric.Float64Histogram
+
+	statusMutex     sync.Mutex
+	report          Report
+	statusListeners []StatusChangeFunc
+
+	watchersMutex sync.Mutex
+	watchers      map[chan Report]struct{}
+
+	unhealthyChecks atomic.Int64
+
+	cacheMutex sync.Mutex
+	cache      evaluationCache
 }
 
 // NewHandler creates a new health check handler for the given service.
 func NewHandler(serviceName string) *Handler {
 	return &Handler{
 		serviceName: serviceName,
-		checks:      make(map[string]CheckFunction),
+		checks:      make(map[string]registeredCheck),
+		aggregation: AggregateAll,
+		report:      Report{Status: StatusUnknown},
+		watchers:    make(map[chan Report]struct{}),
 	}
 }
 
 // RegisterCheck registers a health check function with the given name.
 // This is thread-safe and can be called concurrently during server initialization.
 func (h *Handler) RegisterCheck(name string, checkFn CheckFunction) {
+	h.RegisterCheckWithOptions(name, checkFn, CheckOptions{})
+}
+
+// RegisterCheckWithOptions registers a health check function with metadata
+// that is surfaced in /_hc/checks and detailed reports.
+func (h *Handler) RegisterCheckWithOptions(name string, checkFn CheckFunction, options CheckOptions) {
 	h.checksMutex.Lock()
 	defer h.checksMutex.Unlock()
 
-	h.checks[name] = checkFn
+	h.checks[name] = registeredCheck{function: checkFn, options: options}
+	h.reorder(name)
+	h.invalidateCache()
 	slog.Info("Registered health check", "name", name)
 }
 
+// reorder moves name to its place in h.order after its options changed.
+// The caller must hold checksMutex.
+func (h *Handler) reorder(name string) {
+	h.order = slices.DeleteFunc(h.order, func(ordered string) bool { return ordered == name })
+
+	index, _ := slices.BinarySearchFunc(h.order, name, h.compareChecks)
+	h.order = slices.Insert(h.order, index, name)
+}
+
+// compareChecks orders checks by descending Priority, then by name.
+func (h *Handler) compareChecks(a, b string) int {
+	if c := cmp.Compare(h.checks[b].options.Priority, h.checks[a].options.Priority); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// ExpectChecks declares checks that must be registered before readiness can pass. Until
+// RegisterCheck replaces it, each expected name is a failing readiness check, so health
+// can't turn green while a dependency's check is still being wired. Names already
+// registered are left alone.
+func (h *Handler) ExpectChecks(names ...string) {
+	h.checksMutex.Lock()
+	defer h.checksMutex.Unlock()
+
+	for _, name := range names {
+		if _, ok := h.checks[name]; ok {
+			continue
+		}
+
+		err := fmt.Errorf("%w: %q", ErrExpectedCheckMissing, name)
+		h.checks[name] = registeredCheck{function: func() error { return err }}
+		h.reorder(name)
+		h.invalidateCache()
+		slog.Info("Expecting health check", "name", name)
+	}
+}
+
+// SetCheckEnabled mutes (false) or unmutes (true) the named check, e.g. during a planned
+// downstream maintenance. Muted checks are skipped and reported as StatusMuted.
+// Re-registering a check unmutes it. Unknown names are ignored.
+func (h *Handler) SetCheckEnabled(name string, enabled bool) {
+	h.checksMutex.Lock()
+	defer h.checksMutex.Unlock()
+
+	check, ok := h.checks[name]
+	if !ok {
+		slog.Warn("Cannot mute unknown health check", "name", name)
+		return
+	}
+
+	check.muted = !enabled
+	h.checks[name] = check
+	h.invalidateCache()
+	slog.Info("Health check muted state changed", "name", name, "muted", check.muted)
+}
+
 // Enable activates health checks.
 // Until this is called, health check requests will return 503 Service Unavailable.
+//
+// The first Enable after SetStartTime records the delay in health_check_enable_delay_seconds
+// when metrics are registered.
 func (h *Handler) Enable() {
 	h.enabledMutex.Lock()
 	defer h.enabledMutex.Unlock()
 
+	if !h.enabled && !h.startedAt.IsZero() && h.enableDelay != nil {
+		h.enableDelay.Record(context.Background(), time.Since(h.startedAt).Seconds())
+	}
+
 	h.enabled = true
 	slog.Info("Health check enabled")
 }
 
+// SetStartTime sets when the service started, from which Enable measures the enable delay.
+func (h *Handler) SetStartTime(startedAt time.Time) {
+	h.enabledMutex.Lock()
+	defer h.enabledMutex.Unlock()
+
+	h.startedAt = startedAt
+}
+
 // IsEnabled returns true if health checks are enabled.
 func (h *Handler) IsEnabled() bool {
 	h.enabledMutex.RLock()
@@ -60,39 +207,358 @@ func (h *Handler) IsEnabled() bool {
 	return h.enabled
 }
 
+// SetDraining marks the handler as draining. While draining, health checks fail
+// without running the registered checks, so load balancers stop routing traffic
+// before the process shuts down.
+func (h *Handler) SetDraining(draining bool) {
+	h.enabledMutex.Lock()
+	defer h.enabledMutex.Unlock()
+
+	h.draining = draining
+	slog.Info("Health check draining", "draining", draining)
+}
+
+// BindReadinessToContext marks the handler draining once ctx is done, e.g. when the
+// application starts shutting down or loses a leader lease, so readiness goes 503 without
+// an explicit SetDraining call. It returns immediately; nothing waits on ctx until it is done.
+// The returned stop unbinds ctx, e.g. when a lease is handed over cleanly, and reports
+// whether it did so before draining started, like the stop func of context.AfterFunc.
+func (h *Handler) BindReadinessToContext(ctx context.Context) (stop func() bool) {
+	return context.AfterFunc(ctx, func() {
+		slog.Info("Readiness context done - draining", "cause", context.Cause(ctx))
+		h.SetDraining(true)
+	})
+}
+
+// SetTimeout bounds how long one evaluation of all checks may take. When exceeded,
+// Evaluate returns ErrTimeout; the checks keep running in the background since
+// they cannot be cancelled. Zero (the default) means no bound.
+func (h *Handler) SetTimeout(timeout time.Duration) {
+	h.enabledMutex.Lock()
+	defer h.enabledMutex.Unlock()
+
+	h.timeout = timeout
+}
+
+// SetSlowThreshold logs a warning for every check that takes longer than threshold,
+// as an early signal before checks start timing out. Zero (the default) disables it.
+func (h *Handler) SetSlowThreshold(threshold time.Duration) {
+	h.enabledMutex.Lock()
+	defer h.enabledMutex.Unlock()
+
+	h.slowAfter = threshold
+}
+
+// IsDraining returns true if the handler is draining.
+func (h *Handler) IsDraining() bool {
+	h.enabledMutex.RLock()
+	defer h.enabledMutex.RUnlock()
+
+	return h.draining
+}
+
+// OnStatusChange registers fn to be called whenever the aggregate status changes
+// between evaluations, including the first evaluation after startup (from StatusUnknown).
+// It is not called when consecutive probes produce the same status.
+//
+// Listeners run synchronously on the probe's goroutine, so they must not block;
+// dispatch slow notifications (Slack, PagerDuty) asynchronously.
+func (h *Handler) OnStatusChange(fn StatusChangeFunc) {
+	h.statusMutex.Lock()
+	defer h.statusMutex.Unlock()
+
+	h.statusListeners = append(h.statusListeners, fn)
+}
+
+// Status returns the aggregate status from the most recent evaluation.
+func (h *Handler) Status() Status {
+	h.statusMutex.Lock()
+	defer h.statusMutex.Unlock()
+
+	return h.report.Status
+}
+
+// Report returns the aggregate status and individual check results
+// from the most recent evaluation.
+func (h *Handler) Report() Report {
+	h.statusMutex.Lock()
+	defer h.statusMutex.Unlock()
+
+	return h.report.clone()
+}
+
+// Evaluate runs all registered checks once, exactly as an HTTP probe would,
+// and updates the aggregate status. Returns ErrNotEnabled or ErrDraining without running
+// checks if health checks are not enabled yet or draining, or the first check error if any check fails.
+//
+// This lets non-HTTP transports (e.g. gRPC health) share the same health state.
+func (h *Handler) Evaluate() error {
+	_, err := h.evaluateReadiness()
+	return err
+}
+
+// evaluateReadiness is Evaluate, also returning the report it produced. When no checks
+// ran (not enabled, draining or timed out), the report is unhealthy with err as its Error.
+func (h *Handler) evaluateReadiness() (Report, error) {
+	if !h.IsEnabled() {
+		return errorReport(ErrNotEnabled), ErrNotEnabled
+	}
+
+	if h.IsDraining() {
+		return errorReport(ErrDraining), ErrDraining
+	}
+
+	report, err := h.runChecksWithTimeout(h.runAllChecksCached)
+	if errors.Is(err, ErrTimeout) {
+		// Record the timeout so Status(), watchers and gRPC health agree with the probe.
+		report = errorReport(err)
+	}
+	h.updateReport(report)
+
+	return report, err
+}
+
 // ServeHTTP handles HTTP health check requests.
 // Returns 200 OK if all checks pass, 503 Service Unavailable otherwise.
-func (h *Handler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
-	if !h.IsEnabled() {
-		h.writeResponse(writer, http.StatusServiceUnavailable, "not enabled")
+// With ?all=true, it responds with a JSON GroupsReport of liveness and readiness instead.
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	if req != nil && req.URL.Query().Get("all") == "true" {
+		h.serveGroups(writer)
 		return
 	}
 
-	if err := h.runAllChecks(); err != nil {
-		h.writeResponse(writer, http.StatusServiceUnavailable, "unhealthy")
-		return
+	report, err := h.evaluateReadiness()
+
+	switch {
+	case errors.Is(err, ErrNotEnabled):
+		h.writeResponse(writer, http.StatusServiceUnavailable, "not enabled")
+	case errors.Is(err, ErrDraining):
+		h.writeResponse(writer, http.StatusServiceUnavailable, "draining")
+	case errors.Is(err, ErrTimeout):
+		h.writeResponse(writer, http.StatusServiceUnavailable, "timeout")
+	case err != nil:
+		h.writeResponse(writer, http.StatusServiceUnavailable, h.unhealthyBody(report))
+	default:
+		h.writeResponse(writer, http.StatusOK, "ok")
 	}
+}
 
-	h.writeResponse(writer, http.StatusOK, "ok")
+// runChecksWithTimeout calls run bounded by the configured timeout, on another goroutine
+// whose panics are returned as errors.
+func (h *Handler) runChecksWithTimeout(run func() (Report, error)) (Report, error) {
+	h.enabledMutex.RLock()
+	timeout := h.timeout
+	h.enabledMutex.RUnlock()
+
+	if timeout <= 0 {
+		return run()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		report Report
+		err    error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err := fmt.Errorf("health check panicked: %v", recovered)
+				done <- result{report: errorReport(err), err: err}
+			}
+		}()
+		report, err := run()
+		done <- result{report: report, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.report, res.err
+	case <-ctx.Done():
+		slog.Error("Health check timed out", "service_name", h.serviceName, "timeout", timeout)
+		return Report{}, ErrTimeout
+	}
+}
+
+// runAllChecks runs every registered check in priority order and returns the
+// resulting report, aggregated per the handler's Aggregation, along with the
+// first failure if the aggregate is unhealthy.
+func (h *Handler) runAllChecks() (Report, error) {
+	h.checksMutex.RLock()
+	aggregation := h.aggregation
+	h.checksMutex.RUnlock()
+
+	report, err := h.runChecks("", aggregation)
+	h.recordUnhealthyChecks(report)
+
+	return report, err
 }
 
-func (h *Handler) runAllChecks() error {
+// runChecks runs the registered checks in group (all checks when empty) in priority
+// order, so the first failure reported is from the highest-priority failing check.
+func (h *Handler) runChecks(group Group, aggregation Aggregation) (Report, error) {
+	h.enabledMutex.RLock()
+	slowAfter := h.slowAfter
+	sanitize := h.sanitize
+	h.enabledMutex.RUnlock()
+
+	// Snapshot the checks and run them outside the lock, so a slow or hung check
+	// doesn't block RegisterCheck, SetCheckEnabled and the other mutators.
 	h.checksMutex.RLock()
-	defer h.checksMutex.RUnlock()
-
-	for checkName, checkFn := range h.checks {
-		if err := checkFn(); err != nil {
-			slog.Error(
-				"Health check failed",
-				"service_name", h.serviceName,
-				"check_name", checkName,
-				"error", err,
-			)
-			return err
+	names := make([]string, 0, len(h.order))
+	checks := make([]registeredCheck, 0, len(h.order))
+	for _, checkName := range h.order {
+		check := h.checks[checkName]
+		if group != "" && check.options.group() != group {
+			continue
+		}
+		names = append(names, checkName)
+		checks = append(checks, check)
+	}
+	pool := h.pool
+	h.checksMutex.RUnlock()
+
+	results := make([]CheckResult, len(names))
+	errs := make([]error, len(names))
+	evaluate := func(i int) {
+		results[i], errs[i] = h.runCheck(names[i], checks[i], slowAfter, sanitize)
+	}
+	if pool != nil {
+		pool.run(len(names), evaluate)
+	} else {
+		for i := range names {
+			evaluate(i)
+		}
+	}
+
+	report := Report{
+		Status: StatusHealthy,
+		Checks: results,
+	}
+
+	var firstErr error
+	active, healthy := 0, 0
+	for i, result := range results {
+		switch {
+		case result.Status == StatusMuted:
+		case errs[i] != nil:
+			active++
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		default:
+			active++
+			healthy++
 		}
 	}
 
-	return nil
+	if healthy >= aggregation.required(active) {
+		return report, nil
+	}
+
+	report.Status = StatusUnhealthy
+	if firstErr == nil {
+		firstErr = fmt.Errorf("%d of %d checks healthy, %s required", healthy, active, aggregation)
+	}
+
+	return report, firstErr
+}
+
+// runCheck runs one check, logging it when slow or failing, and returns its result with
+// the error passed through sanitize. Muted checks are not run.
+func (h *Handler) runCheck(
+	checkName string,
+	check registeredCheck,
+	slowAfter time.Duration,
+	sanitize ErrorSanitizer,
+) (CheckResult, error) {
+	result := CheckResult{
+		Name:        checkName,
+		Description: check.options.Description,
+		Status:      StatusHealthy,
+	}
+
+	if check.muted {
+		result.Status = StatusMuted
+		return result, nil
+	}
+
+	started := time.Now()
+	err := callCheck(check.function)
+	if elapsed := time.Since(started); slowAfter > 0 && elapsed > slowAfter {
+		slog.Warn(
+			"Health check is slow",
+			"service_name", h.serviceName,
+			"check_name", checkName,
+			"duration", elapsed,
+			"threshold", slowAfter,
+		)
+	}
+
+	if err != nil {
+		slog.Error(
+			"Health check failed",
+			"service_name", h.serviceName,
+			"check_name", checkName,
+			"error", err,
+		)
+
+		result.Status = StatusUnhealthy
+		result.Error = sanitize.apply(err)
+		var composite *CompositeError
+		if errors.As(err, &composite) {
+			result.Subchecks = composite.sanitizedResults(sanitize)
+		}
+	}
+
+	return result, err
+}
+
+// callCheck calls function, turning a panic into its error so one broken check is
+// reported unhealthy instead of crashing the probe or the worker running it.
+func callCheck(function CheckFunction) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("health check panicked: %v", recovered)
+		}
+	}()
+
+	return function()
+}
+
+func (h *Handler) updateReport(report Report) {
+	h.statusMutex.Lock()
+	oldReport := h.report
+	if oldReport.equal(report) {
+		h.statusMutex.Unlock()
+		return
+	}
+
+	h.report = report
+	listeners := append([]StatusChangeFunc(nil), h.statusListeners...)
+	// Notify under statusMutex so concurrent evaluations reach watchers in the order
+	// their reports were recorded, and a stale report never overwrites a newer one.
+	h.notifyWatchers(report)
+	h.statusMutex.Unlock()
+
+	oldStatus, newStatus := oldReport.Status, report.Status
+	if oldStatus == newStatus {
+		return
+	}
+
+	slog.Info(
+		"Health status changed",
+		"service_name", h.serviceName,
+		"old_status", oldStatus,
+		"new_status", newStatus,
+	)
+
+	for _, listener := range listeners {
+		listener(oldStatus, newStatus)
+	}
 }
 
 func (h *Handler) writeResponse(writer http.ResponseWriter, statusCode int, message string) {
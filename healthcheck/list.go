@@ -0,0 +1,75 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// CheckOptions holds optional metadata for a registered check.
+type CheckOptions struct {
+	// Description is a human-readable summary, e.g. "Primary Postgres connectivity"
+	Description string
+	// Group is the probe the check belongs to. Empty means GroupReadiness.
+	Group Group
+	// Priority orders evaluation: higher priorities run first and equal priorities run in
+	// name order, so give cheap or critical checks a higher priority to make their failure
+	// the one reported. Zero is the default.
+	Priority int
+}
+
+func (o CheckOptions) group() Group {
+	if o.Group == "" {
+		return GroupReadiness
+	}
+	return o.Group
+}
+
+// CheckInfo describes a registered check without running it.
+// Group is as registered, so empty means GroupReadiness.
+type CheckInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Group       Group  `json:"group,omitempty"`
+	Muted       bool   `json:"muted,omitempty"`
+	Priority    int    `json:"priority,omitempty"`
+}
+
+type registeredCheck struct {
+	function CheckFunction
+	options  CheckOptions
+	muted    bool
+}
+
+// ListChecks returns the registered checks ordered by name.
+func (h *Handler) ListChecks() []CheckInfo {
+	h.checksMutex.RLock()
+	defer h.checksMutex.RUnlock()
+
+	infos := make([]CheckInfo, 0, len(h.checks))
+	for name, check := range h.checks {
+		infos = append(
+			infos, CheckInfo{
+				Name:        name,
+				Description: check.options.Description,
+				Group:       check.options.Group,
+				Muted:       check.muted,
+				Priority:    check.options.Priority,
+			},
+		)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
+
+// ChecksHandler returns an http.Handler that lists the registered checks as JSON.
+// It does not run any checks.
+func (h *Handler) ChecksHandler() http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			writer.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(writer).Encode(h.ListChecks())
+		},
+	)
+}
@@ -0,0 +1,61 @@
+package healthcheck
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// UnhealthyChecksMetricName is the gauge reporting how many registered checks
+// failed on the most recent evaluation.
+const UnhealthyChecksMetricName = "health_unhealthy_checks"
+
+// EnableDelayMetricName is the histogram recording how long after SetStartTime
+// health checks were enabled, observed once per process.
+const EnableDelayMetricName = "health_check_enable_delay_seconds"
+
+// RegisterMetrics registers the health_unhealthy_checks gauge and the
+// health_check_enable_delay_seconds histogram on the given meter.
+// The gauge reads the count stored by the most recent evaluation, so it is 0
+// until the first probe runs.
+func (h *Handler) RegisterMetrics(meter metric.Meter) error {
+	enableDelay, err := meter.Float64Histogram(
+		EnableDelayMetricName,
+		metric.WithDescription("Seconds from server start until health checks were enabled"),
+		metric.WithExplicitBucketBoundaries(0.5, 1, 2, 5, 10, 15, 30, 60, 120, 300),
+	)
+	if err != nil {
+		return err
+	}
+
+	h.enabledMutex.Lock()
+	h.enableDelay = enableDelay
+	h.enabledMutex.Unlock()
+
+	_, err = meter.Int64ObservableGauge(
+		UnhealthyChecksMetricName,
+		metric.WithDescription("Number of registered health checks failing on the most recent evaluation"),
+		metric.WithInt64Callback(
+			func(_ context.Context, observer metric.Int64Observer) error {
+				observer.Observe(h.UnhealthyChecks())
+				return nil
+			},
+		),
+	)
+	return err
+}
+
+// UnhealthyChecks returns how many checks failed on the most recent evaluation.
+func (h *Handler) UnhealthyChecks() int64 {
+	return h.unhealthyChecks.Load()
+}
+
+func (h *Handler) recordUnhealthyChecks(report Report) {
+	var unhealthy int64
+	for _, result := range report.Checks {
+		if result.Status == StatusUnhealthy {
+			unhealthy++
+		}
+	}
+	h.unhealthyChecks.Store(unhealthy)
+}
@@ -0,0 +1,88 @@
+package healthcheck
+
+import (
+	"sync"
+	"time"
+)
+
+// poolIdleTimeout is how long a check worker waits for more work before exiting, so
+// workers are reused across frequent probes but don't linger once probing stops.
+const poolIdleTimeout = time.Minute
+
+// checkPool runs checks on at most size worker goroutines, shared by every evaluation,
+// so concurrent probes of /_hc, /_hc/ready and gRPC Check can't run more than size checks
+// at once between them. Workers are started on demand and reused until they sit idle for
+// poolIdleTimeout; each holds a slot in workers while it lives.
+type checkPool struct {
+	workers chan struct{}
+	tasks   chan func()
+}
+
+func newCheckPool(size int) *checkPool {
+	return &checkPool{
+		workers: make(chan struct{}, size),
+		tasks:   make(chan func()),
+	}
+}
+
+// SetConcurrency runs up to n checks at once, counted across all evaluations in flight, on
+// worker goroutines reused across probes rather than one goroutine per check. Results keep
+// the evaluation order, so reports and the first failure are the same as when running one
+// at a time. Values below 2 run checks one after another, which is the default.
+func (h *Handler) SetConcurrency(n int) {
+	h.checksMutex.Lock()
+	defer h.checksMutex.Unlock()
+
+	if n < 2 {
+		h.pool = nil
+		return
+	}
+	h.pool = newCheckPool(n)
+}
+
+// run calls task for every index below n on the pool's workers and waits for all of them.
+func (p *checkPool) run(n int, task func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		p.submit(func() {
+			defer wg.Done()
+			task(i)
+		})
+	}
+	wg.Wait()
+}
+
+// submit hands task to an idle worker, or starts a new worker while fewer than size are
+// running. Otherwise it waits for a worker to finish its task or exit.
+func (p *checkPool) submit(task func()) {
+	select {
+	case p.tasks <- task:
+		return
+	default:
+	}
+
+	select {
+	case p.tasks <- task:
+	case p.workers <- struct{}{}:
+		go p.work(task)
+	}
+}
+
+func (p *checkPool) work(task func()) {
+	defer func() { <-p.workers }()
+
+	idle := time.NewTimer(poolIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		task()
+
+		idle.Reset(poolIdleTimeout)
+		select {
+		case task = <-p.tasks:
+		case <-idle.C:
+			return
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package healthcheck
+
+import "slices"
+
+// CheckResult is the outcome of a single check in an evaluation.
+type CheckResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+	// Subchecks lists the sub-results of a failing CompositeCheck.
+	Subchecks []CheckResult `json:"subchecks,omitempty"`
+}
+
+func (c CheckResult) equal(other CheckResult) bool {
+	return c.Name == other.Name && c.Description == other.Description && c.Status == other.Status &&
+		c.Error == other.Error && slices.EqualFunc(c.Subchecks, other.Subchecks, CheckResult.equal)
+}
+
+// Report is a snapshot of the aggregate status and the individual check results
+// from one evaluation. Checks are ordered by descending Priority, then by name.
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+	// Error explains an unhealthy report when no checks ran, e.g. "health check draining".
+	Error string `json:"error,omitempty"`
+}
+
+func errorReport(err error) Report {
+	return Report{Status: StatusUnhealthy, Checks: []CheckResult{}, Error: err.Error()}
+}
+
+func (r Report) clone() Report {
+	r.Checks = append([]CheckResult(nil), r.Checks...)
+	return r
+}
+
+func (r Report) equal(other Report) bool {
+	return r.Status == other.Status && r.Error == other.Error &&
+		slices.EqualFunc(r.Checks, other.Checks, CheckResult.equal)
+}
@@ -0,0 +1,23 @@
+package healthcheck
+
+// ErrorSanitizer turns a failing check's error into the string reported for it, e.g. to
+// strip connection strings with credentials before they reach a health response.
+type ErrorSanitizer func(err error) string
+
+// SetErrorSanitizer makes check errors appear as sanitize(err) in reports, the JSON and
+// plain-text health responses and the gRPC and watch streams, including the subchecks of a
+// CompositeCheck. Logs keep the original error. Nil restores the default, err.Error().
+func (h *Handler) SetErrorSanitizer(sanitize ErrorSanitizer) {
+	h.enabledMutex.Lock()
+	defer h.enabledMutex.Unlock()
+
+	h.sanitize = sanitize
+	h.invalidateCache()
+}
+
+func (s ErrorSanitizer) apply(err error) string {
+	if s == nil {
+		return err.Error()
+	}
+	return s(err)
+}
@@ -0,0 +1,94 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Subscribe returns a channel that receives the latest report whenever the aggregate
+// status or any individual check result changes, and a function to unsubscribe.
+//
+// Reports are produced by evaluations (probes, Evaluate calls); nothing is sent while
+// no one evaluates. Slow subscribers only ever see the most recent report.
+func (h *Handler) Subscribe() (<-chan Report, func()) {
+	watcher := make(chan Report, 1)
+
+	h.watchersMutex.Lock()
+	h.watchers[watcher] = struct{}{}
+	h.watchersMutex.Unlock()
+
+	unsubscribe := func() {
+		h.watchersMutex.Lock()
+		defer h.watchersMutex.Unlock()
+
+		delete(h.watchers, watcher)
+	}
+
+	return watcher, unsubscribe
+}
+
+func (h *Handler) notifyWatchers(report Report) {
+	h.watchersMutex.Lock()
+	defer h.watchersMutex.Unlock()
+
+	for watcher := range h.watchers {
+		// Replace any undelivered report so the watcher never blocks evaluation
+		select {
+		case <-watcher:
+		default:
+		}
+		watcher <- report.clone()
+	}
+}
+
+// WatchHandler returns an http.Handler that streams reports as Server-Sent Events.
+// The current report is sent immediately, then one event per change until the
+// client disconnects or the request context is cancelled (e.g. on server shutdown).
+func (h *Handler) WatchHandler() http.Handler {
+	return http.HandlerFunc(h.serveWatch)
+}
+
+func (h *Handler) serveWatch(writer http.ResponseWriter, req *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		http.Error(writer, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	reports, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.WriteHeader(http.StatusOK)
+
+	if err := writeEvent(writer, h.Report()); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+
+		case report := <-reports:
+			if err := writeEvent(writer, report); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(writer http.ResponseWriter, report Report) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(writer, "data: %s\n\n", payload)
+	return err
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RequestsMetricName is the counter of requests served by the internal router,
+// labelled by method, route and status code.
+const RequestsMetricName = "internal_server_requests_total"
+
+// ResponseSizeMetricName is the histogram of response body sizes in bytes on the internal
+// router, with the same labels as RequestsMetricName. For /metrics it tracks payload growth.
+const ResponseSizeMetricName = "http_server_response_size_bytes"
+
+// PanicsMetricName is the counter of handler panics recovered on the internal router,
+// labelled by route.
+const PanicsMetricName = "http_handler_panics_total"
+
+const (
+	profilingPathLabel = "/debug/pprof/*"
+	unmatchedPathLabel = "unmatched"
+)
+
+// instrumentRequests counts requests and measures response sizes by gin's route template
+// rather than the raw URL, so query strings and unknown paths can't inflate label
+// cardinality. All profiling routes share the profilingPathLabel.
+func instrumentRequests(meter metric.Meter) (gin.HandlerFunc, error) {
+	requests, err := meter.Int64Counter(
+		RequestsMetricName,
+		metric.WithDescription("Requests served by the internal telemetry server"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	responseSize, err := meter.Int64Histogram(
+		ResponseSizeMetricName,
+		metric.WithDescription("Response body sizes of the internal telemetry server in bytes"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		attributes := metric.WithAttributes(
+			attribute.String("method", c.Request.Method),
+			attribute.String("path", pathLabel(c.FullPath())),
+			attribute.String("code", strconv.Itoa(c.Writer.Status())),
+		)
+		requests.Add(c.Request.Context(), 1, attributes)
+		// gin's ResponseWriter counts body bytes; Size is -1 when nothing was written
+		responseSize.Record(c.Request.Context(), int64(max(c.Writer.Size(), 0)), attributes)
+	}, nil
+}
+
+// RecoveryWithMetrics returns middleware that counts PanicsMetricName for every handler
+// panic and then re-panics, so it must be used after gin.Recovery() (or another recovery
+// middleware), which still logs the panic and responds with 500. It also works on gin
+// engines other than the internal router.
+func RecoveryWithMetrics(meter metric.Meter) (gin.HandlerFunc, error) {
+	panics, err := meter.Int64Counter(
+		PanicsMetricName,
+		metric.WithDescription("Handler panics recovered by the internal telemetry server"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				panics.Add(
+					c.Request.Context(), 1, metric.WithAttributes(
+						attribute.String("path", pathLabel(c.FullPath())),
+					),
+				)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+	}, nil
+}
+
+func pathLabel(route string) string {
+	switch {
+	case route == "":
+		return unmatchedPathLabel
+	case strings.HasPrefix(route, "/debug/pprof/"):
+		return profilingPathLabel
+	default:
+		return route
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const profilingPathPrefix = "/debug/pprof/"
+
+type connContextKey struct{}
+
+// profilingRequests lets in-flight /debug/pprof/ requests outlive the start of shutdown
+// by a grace period, then cancels them and closes their connections, so a long CPU
+// profile can't hold up Shutdown while scrapes and other requests finish normally.
+type profilingRequests struct {
+	grace time.Duration
+
+	mutex    sync.Mutex
+	inFlight map[*profilingRequest]struct{}
+	closed   bool
+}
+
+type profilingRequest struct {
+	cancel context.CancelFunc
+	conn   net.Conn
+}
+
+func newProfilingRequests(grace time.Duration) *profilingRequests {
+	return &profilingRequests{
+		grace:    grace,
+		inFlight: make(map[*profilingRequest]struct{}),
+	}
+}
+
+// connContext records each connection in its context, so a profiling request can
+// close its own connection once the grace period ends.
+func (p *profilingRequests) connContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// wrap tracks in-flight profiling requests so they can be cancelled once the grace
+// period is over. A client disconnect still cancels them as usual.
+func (p *profilingRequests) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			if !strings.HasPrefix(req.URL.Path, profilingPathPrefix) {
+				next.ServeHTTP(writer, req)
+				return
+			}
+
+			ctx, cancel := context.WithCancel(req.Context())
+			defer cancel()
+
+			conn, _ := req.Context().Value(connContextKey{}).(net.Conn)
+			request := &profilingRequest{cancel: cancel, conn: conn}
+			if !p.add(request) {
+				cancel()
+			}
+			defer p.remove(request)
+
+			next.ServeHTTP(writer, req.WithContext(ctx))
+		},
+	)
+}
+
+func (p *profilingRequests) add(request *profilingRequest) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return false
+	}
+	p.inFlight[request] = struct{}{}
+	return true
+}
+
+func (p *profilingRequests) remove(request *profilingRequest) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.inFlight, request)
+}
+
+// beginShutdown ends in-flight profiling requests after the grace period.
+func (p *profilingRequests) beginShutdown() {
+	time.AfterFunc(p.grace, p.closeAll)
+}
+
+func (p *profilingRequests) closeAll() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+	for request := range p.inFlight {
+		request.cancel()
+		if request.conn != nil {
+			_ = request.conn.Close()
+		}
+	}
+}
@@ -1,35 +1,125 @@
 package http
 
 import (
+	"crypto/subtle"
+	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/domesama/doakes/healthcheck"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // RouterConfig contains handlers for the internal server routes.
 type RouterConfig struct {
 	HealthCheckHandler http.Handler
-	MetricsHandler     http.Handler
-	IndexHandler       gin.HandlerFunc
+	// HealthCheckAliases are extra paths serving HealthCheckHandler. Aliases taken by another
+	// route are skipped with an error log.
+	HealthCheckAliases      []string
+	HealthCheckWatchHandler http.Handler
+	HealthCheckListHandler  http.Handler
+	LivenessHandler         http.Handler
+	ReadinessHandler        http.Handler
+	MetricsHandler          http.Handler
+	IndexHandler            gin.HandlerFunc
+	// TrustedProxies are passed to gin's SetTrustedProxies. Nil trusts no proxies.
+	TrustedProxies []string
+	// Mode is passed to gin.SetMode before the router is created. Empty means gin.ReleaseMode.
+	Mode string
+	// MetricsToken, when set, is required as "Authorization: Bearer <token>" on /metrics.
+	MetricsToken string
+	// Meter, when set, records RequestsMetricName and ResponseSizeMetricName for every
+	// request to the router and PanicsMetricName for every handler panic.
+	Meter metric.Meter
+	// ConfigHandler, when set, serves /debug/config next to /debug/pprof/, behind MetricsToken when set.
+	ConfigHandler http.Handler
+	// HealthRouteTimeout and MetricsRouteTimeout answer 503 to requests to the health check
+	// routes (except the /_hc/watch stream) and /metrics running longer. ProfilingRouteTimeout
+	// bounds the context of /debug/pprof/ requests instead, so a long profile ends early with
+	// what it collected. Zero means no bound.
+	HealthRouteTimeout    time.Duration
+	MetricsRouteTimeout   time.Duration
+	ProfilingRouteTimeout time.Duration
 }
 
 // NewRouter creates a new Gin router with all internal server routes registered.
+// gin's mode is process-wide, so this also affects other gin engines in the process.
 func NewRouter(config RouterConfig) *gin.Engine {
+	router := newEngine(config)
+	registerAllRoutes(router, config)
+
+	return router
+}
+
+// NewHealthRouter creates a Gin router with only the health check routes (/_hc, its
+// aliases, /_hc/watch, /_hc/checks and the probes), e.g. for a plain HTTP listener next
+// to an mTLS-protected internal port.
+func NewHealthRouter(config RouterConfig) *gin.Engine {
+	router := newEngine(config)
+	registerHealthRoutes(router, config)
+	registerHealthCheckAliases(
+		router, withTimeout(config.HealthCheckHandler, config.HealthRouteTimeout), config.HealthCheckAliases,
+	)
+
+	return router
+}
+
+// newEngine creates a gin engine with the middleware shared by every internal router.
+func newEngine(config RouterConfig) *gin.Engine {
+	mode := config.Mode
+	if mode == "" {
+		mode = gin.ReleaseMode
+	}
+	gin.SetMode(mode)
+
 	router := gin.New()
 	router.Use(gin.Recovery())
 
-	registerAllRoutes(router, config)
+	if config.Meter != nil {
+		if recovery, err := RecoveryWithMetrics(config.Meter); err != nil {
+			slog.Error("Failed to create panic metrics - not counting handler panics", "error", err)
+		} else {
+			router.Use(recovery)
+		}
+
+		if instrument, err := instrumentRequests(config.Meter); err != nil {
+			slog.Error("Failed to create request metrics - not instrumenting the internal router", "error", err)
+		} else {
+			router.Use(instrument)
+		}
+	}
+
+	// Setting trusted proxies explicitly also silences gin's "trusted all proxies" warning
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		slog.Error("Invalid trusted proxies - trusting none", "trusted_proxies", config.TrustedProxies, "error", err)
+		_ = router.SetTrustedProxies(nil)
+	}
 
 	return router
 }
 
 func registerAllRoutes(router *gin.Engine, config RouterConfig) {
 	registerIndexRoute(router, config.IndexHandler)
-	registerHealthCheckRoute(router, config.HealthCheckHandler)
-	registerMetricsRoute(router, config.MetricsHandler)
-	registerProfilingRoutes(router)
+	registerHealthRoutes(router, config)
+	registerMetricsRoute(router, withTimeout(config.MetricsHandler, config.MetricsRouteTimeout), config.MetricsToken)
+	registerProfilingRoutes(router, config.ProfilingRouteTimeout)
+	registerConfigRoute(router, config.ConfigHandler, config.MetricsToken)
+	registerHealthCheckAliases(
+		router, withTimeout(config.HealthCheckHandler, config.HealthRouteTimeout), config.HealthCheckAliases,
+	)
+}
+
+func registerHealthRoutes(router *gin.Engine, config RouterConfig) {
+	timeout := config.HealthRouteTimeout
+	registerHealthCheckRoute(router, withTimeout(config.HealthCheckHandler, timeout))
+	registerHealthCheckWatchRoute(router, config.HealthCheckWatchHandler)
+	registerHealthCheckListRoute(router, withTimeout(config.HealthCheckListHandler, timeout))
+	registerProbeRoutes(
+		router, withTimeout(config.LivenessHandler, timeout), withTimeout(config.ReadinessHandler, timeout),
+	)
 }
 
 func registerIndexRoute(router *gin.Engine, handler gin.HandlerFunc) {
@@ -40,25 +130,116 @@ func registerHealthCheckRoute(router *gin.Engine, handler http.Handler) {
 	router.GET("/_hc", gin.WrapH(handler))
 }
 
-func registerMetricsRoute(router *gin.Engine, handler http.Handler) {
-	router.GET("/metrics", gin.WrapH(handler))
+// registerHealthCheckAliases runs after every built-in route is registered, so an alias
+// that would shadow one is detected and skipped instead of panicking in gin.
+func registerHealthCheckAliases(router *gin.Engine, handler http.Handler, aliases []string) {
+	for _, alias := range aliases {
+		taken := slices.ContainsFunc(router.Routes(), func(route gin.RouteInfo) bool {
+			return route.Method == http.MethodGet && route.Path == alias
+		})
+		if taken {
+			slog.Error("Health check path alias conflicts with an existing route - skipping", "alias", alias)
+			continue
+		}
+
+		router.GET(alias, gin.WrapH(handler))
+	}
+}
+
+func registerHealthCheckWatchRoute(router *gin.Engine, handler http.Handler) {
+	if handler == nil {
+		return
+	}
+	router.GET(healthCheckWatchPath, gin.WrapH(handler))
 }
 
-func registerProfilingRoutes(router *gin.Engine) {
-	profilingGroup := router.Group("/debug/pprof/")
+func registerHealthCheckListRoute(router *gin.Engine, handler http.Handler) {
+	if handler == nil {
+		return
+	}
+	router.GET("/_hc/checks", gin.WrapH(handler))
+}
+
+func registerProbeRoutes(router *gin.Engine, liveness http.Handler, readiness http.Handler) {
+	if liveness != nil {
+		router.GET("/_hc/live", gin.WrapH(liveness))
+	}
+	if readiness != nil {
+		router.GET("/_hc/ready", gin.WrapH(readiness))
+	}
+}
+
+func registerMetricsRoute(router *gin.Engine, handler http.Handler, token string) {
+	if token == "" {
+		router.GET("/metrics", gin.WrapH(handler))
+		return
+	}
+	router.GET("/metrics", requireBearerToken(token), gin.WrapH(handler))
+}
+
+// requireBearerToken aborts with 401 unless the request carries "Authorization: Bearer <token>".
+func requireBearerToken(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+
+	return func(c *gin.Context) {
+		provided := []byte(c.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(provided, expected) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	}
+}
+
+func registerProfilingRoutes(router *gin.Engine, timeout time.Duration) {
+	profilingGroup := router.Group("/debug/pprof/", requestDeadline(timeout))
 	pprof.RouteRegister(profilingGroup, "")
+	router.GET("/debug/stats", runtimeStatsHandler)
+}
+
+func registerConfigRoute(router *gin.Engine, handler http.Handler, token string) {
+	if handler == nil {
+		return
+	}
+	if token == "" {
+		router.GET("/debug/config", gin.WrapH(handler))
+		return
+	}
+	router.GET("/debug/config", requireBearerToken(token), gin.WrapH(handler))
+}
+
+// IndexInfo is the basic service information served on the index route.
+type IndexInfo struct {
+	Service string `json:"service"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
 }
 
+// IndexFormatter converts IndexInfo into the value serialized as the index response,
+// e.g. a struct with different JSON tags or a "component" field instead of "service".
+type IndexFormatter func(info IndexInfo) any
+
 // CreateIndexHandler creates a handler that returns basic service information.
 func CreateIndexHandler(serviceName string, serviceVersion string) gin.HandlerFunc {
+	return CreateIndexHandlerWithFormatter(serviceName, serviceVersion, nil)
+}
+
+// CreateIndexHandlerWithFormatter is CreateIndexHandler serializing format(info) instead
+// of IndexInfo. A nil format serializes IndexInfo as-is.
+func CreateIndexHandlerWithFormatter(serviceName string, serviceVersion string, format IndexFormatter) gin.HandlerFunc {
+	info := IndexInfo{
+		Service: serviceName,
+		Version: serviceVersion,
+		Status:  "running",
+	}
+
 	return func(c *gin.Context) {
-		c.JSON(
-			http.StatusOK, gin.H{
-				"service": serviceName,
-				"version": serviceVersion,
-				"status":  "running",
-			},
-		)
+		if format == nil {
+			c.JSON(http.StatusOK, info)
+			return
+		}
+		c.JSON(http.StatusOK, format(info))
 	}
 }
 
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuntimeStats is the body served on /debug/stats.
+type RuntimeStats struct {
+	Goroutines      int    `json:"goroutines"`
+	NumCPU          int    `json:"num_cpu"`
+	GOMAXPROCS      int    `json:"gomaxprocs"`
+	HeapAllocBytes  uint64 `json:"heap_alloc_bytes"`
+	HeapInuseBytes  uint64 `json:"heap_inuse_bytes"`
+	HeapObjects     uint64 `json:"heap_objects"`
+	NumGC           uint32 `json:"num_gc"`
+	LastGC          string `json:"last_gc,omitempty"`
+	PauseTotal      string `json:"pause_total"`
+	NextGCHeapBytes uint64 `json:"next_gc_heap_bytes"`
+}
+
+// runtimeStatsHandler serves a RuntimeStats snapshot as JSON, a quicker look than a full
+// goroutine or heap profile. runtime.ReadMemStats briefly stops the world, as pprof's heap
+// endpoint does.
+func runtimeStatsHandler(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := RuntimeStats{
+		Goroutines:      runtime.NumGoroutine(),
+		NumCPU:          runtime.NumCPU(),
+		GOMAXPROCS:      runtime.GOMAXPROCS(0),
+		HeapAllocBytes:  memStats.HeapAlloc,
+		HeapInuseBytes:  memStats.HeapInuse,
+		HeapObjects:     memStats.HeapObjects,
+		NumGC:           memStats.NumGC,
+		PauseTotal:      time.Duration(memStats.PauseTotalNs).String(),
+		NextGCHeapBytes: memStats.NextGC,
+	}
+	if memStats.LastGC != 0 {
+		stats.LastGC = time.Unix(0, int64(memStats.LastGC)).UTC().Format(time.RFC3339Nano)
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -3,6 +3,8 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"net"
 	"net/http"
 	"sync"
@@ -14,46 +16,154 @@ const (
 	defaultShutdownTimeout   = 5 * time.Second
 )
 
+// ServerConfig contains tunables for the underlying HTTP server.
+type ServerConfig struct {
+	// MaxHeaderBytes caps the size of request headers. Zero uses the net/http default.
+	MaxHeaderBytes int
+	// Network is passed to net.Listen ("tcp", "tcp4" or "tcp6"). Empty means "tcp".
+	Network string
+	// ProfilingShutdownGrace is how long in-flight /debug/pprof/ requests keep running once
+	// shutdown starts before their connections are closed. Zero ends them immediately.
+	// Other in-flight requests are waited for until the shutdown context ends.
+	ProfilingShutdownGrace time.Duration
+	// TLSConfig, when set, serves HTTPS with it. Its ClientAuth decides whether connections
+	// without a valid client certificate are rejected during the handshake.
+	TLSConfig *tls.Config
+}
+
 // Server wraps the standard HTTP server with sensible defaults.
 type Server struct {
 	httpServer *http.Server
+	network    string
+	tlsConfig  *tls.Config
 	listener   net.Listener
 	mutex      sync.RWMutex
+	profiling  *profilingRequests
+
+	listening     chan struct{}
+	listeningOnce sync.Once
 }
 
 // NewServer creates a new HTTP server with the given router.
-func NewServer(router http.Handler) *Server {
+func NewServer(router http.Handler, config ServerConfig) *Server {
+	// Streams are cancelled when shutdown begins, so they end instead of holding up
+	// Shutdown, while other in-flight requests are left to finish
+	streams, cancelStreams := context.WithCancel(context.Background())
+	profiling := newProfilingRequests(config.ProfilingShutdownGrace)
+
 	httpServer := &http.Server{
-		Handler:           router,
+		Handler:           profiling.wrap(endStreamsOnShutdown(router, streams)),
 		ReadHeaderTimeout: defaultReadHeaderTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+		ConnContext:       profiling.connContext,
+	}
+	httpServer.RegisterOnShutdown(cancelStreams)
+
+	network := config.Network
+	if network == "" {
+		network = "tcp"
 	}
 
 	return &Server{
 		httpServer: httpServer,
+		network:    network,
+		tlsConfig:  config.TLSConfig,
+		profiling:  profiling,
+		listening:  make(chan struct{}),
 	}
 }
 
 // Start begins serving HTTP requests on the specified address.
 func (s *Server) Start(address string) error {
-	listener, err := net.Listen("tcp", address)
+	if err := s.Listen(address); err != nil {
+		return err
+	}
+
+	return s.Serve()
+}
+
+// Listen binds the listener for the specified address without serving requests.
+// Binding up front lets callers handle errors such as the port being in use
+// before serving in the background.
+func (s *Server) Listen(address string) error {
+	listener, err := net.Listen(s.network, address)
 	if err != nil {
 		return err
 	}
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
 
 	s.mutex.Lock()
 	s.listener = listener
 	s.httpServer.Addr = listener.Addr().String()
 	s.mutex.Unlock()
 
+	s.listeningOnce.Do(func() { close(s.listening) })
+
+	return nil
+}
+
+// CloseListener closes the listener bound by Listen without shutting the server down,
+// so a failed start can be rolled back and Listen called again. Call it only before Serve.
+func (s *Server) CloseListener() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	s.listener = nil
+	return err
+}
+
+// Listening returns a channel that is closed once Listen has bound the listener.
+func (s *Server) Listening() <-chan struct{} {
+	return s.listening
+}
+
+// Serve serves HTTP requests on the listener bound by Listen.
+func (s *Server) Serve() error {
+	s.mutex.RLock()
+	listener := s.listener
+	s.mutex.RUnlock()
+
+	if listener == nil {
+		return errors.New("server is not listening")
+	}
+
 	return s.httpServer.Serve(listener)
 }
 
 // Shutdown gracefully stops the HTTP server.
 func (s *Server) Shutdown() error {
-	shutdownContext, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
-	defer cancel()
+	return s.ShutdownContext(context.Background())
+}
+
+// ShutdownContext gracefully stops the HTTP server, waiting for in-flight requests
+// until ctx is done. A ctx without a deadline is bounded by the default shutdown timeout.
+// Profiling requests are cut off after ServerConfig.ProfilingShutdownGrace instead.
+func (s *Server) ShutdownContext(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultShutdownTimeout)
+		defer cancel()
+	}
+
+	s.profiling.beginShutdown()
+	err := s.httpServer.Shutdown(ctx)
+
+	// Serve may not have picked up the listener yet, in which case
+	// http.Server doesn't know about it and won't close it
+	s.mutex.RLock()
+	listener := s.listener
+	s.mutex.RUnlock()
+	if listener != nil {
+		_ = listener.Close()
+	}
 
-	return s.httpServer.Shutdown(shutdownContext)
+	return err
 }
 
 // Address returns the server's configured address (may be ":0" if dynamic port).
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+const healthCheckWatchPath = "/_hc/watch"
+
+// endStreamsOnShutdown cancels long-lived streams (e.g. /_hc/watch) once streams is done,
+// so they end instead of holding up Shutdown. Other requests keep their own context and
+// are waited for by Shutdown as usual.
+func endStreamsOnShutdown(next http.Handler, streams context.Context) http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != healthCheckWatchPath {
+				next.ServeHTTP(writer, req)
+				return
+			}
+
+			ctx, cancel := context.WithCancel(req.Context())
+			defer cancel()
+
+			stop := context.AfterFunc(streams, cancel)
+			defer stop()
+
+			next.ServeHTTP(writer, req.WithContext(ctx))
+		},
+	)
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withTimeout answers 503 "timeout" when handler runs longer than timeout, via
+// http.TimeoutHandler. The response is buffered, so it is only for non-streaming routes.
+// Zero or a nil handler returns handler unchanged.
+func withTimeout(handler http.Handler, timeout time.Duration) http.Handler {
+	if handler == nil || timeout <= 0 {
+		return handler
+	}
+	return http.TimeoutHandler(handler, timeout, "timeout")
+}
+
+// requestDeadline bounds the request context by timeout for handlers that stop on their own
+// once it is done, such as pprof's CPU profile and trace, which then return what they
+// collected so far. Zero leaves requests unbounded.
+func requestDeadline(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
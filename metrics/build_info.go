@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"errors"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// registerBuildInfo registers a constant 1-valued gauge labelled with version, revision
+// and goversion, for joining deploy metadata in PromQL. An empty name disables it.
+//
+// version comes from the resource's service.version, falling back to the main module
+// version; revision comes from the VCS information stamped by the Go toolchain.
+func registerBuildInfo(registry *prometheus.Registry, name string, res *resource.Resource) error {
+	if name == "" {
+		return nil
+	}
+
+	version, revision, goVersion := readBuildInfo(res)
+
+	gauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: name,
+			Help: "Build information. Always 1; join on the labels.",
+			ConstLabels: prometheus.Labels{
+				"version":   version,
+				"revision":  revision,
+				"goversion": goVersion,
+			},
+		},
+	)
+	gauge.Set(1)
+
+	err := registry.Register(gauge)
+
+	// A shared registry may already have it from a previous provider
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+
+	return err
+}
+
+func readBuildInfo(res *resource.Resource) (version, revision, goVersion string) {
+	version = "unknown"
+	revision = "unknown"
+	goVersion = runtime.Version()
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+			version = buildInfo.Main.Version
+		}
+
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+
+	if res != nil {
+		if value, ok := res.Set().Value(semconv.ServiceVersionKey); ok {
+			version = value.AsString()
+		}
+	}
+
+	return version, revision, goVersion
+}
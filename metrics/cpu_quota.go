@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cgroupCPUMaxPath is the cgroup v2 file holding the container CPU quota and period.
+const cgroupCPUMaxPath = "/sys/fs/cgroup/cpu.max"
+
+// registerCPUQuota registers a gauge reporting the cgroup CPU quota in cores, read on
+// every scrape so it can be compared with go_processor_limit (GOMAXPROCS). It reports
+// nothing when there is no quota (e.g. outside a container). An empty name disables it.
+func registerCPUQuota(registry *prometheus.Registry, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	collector := &cpuQuotaCollector{
+		desc: prometheus.NewDesc(name, "CPU quota of the container's cgroup, in cores.", nil, nil),
+		path: cgroupCPUMaxPath,
+	}
+
+	err := registry.Register(collector)
+
+	// A shared registry may already have it from a previous provider
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+
+	return err
+}
+
+type cpuQuotaCollector struct {
+	desc *prometheus.Desc
+	path string
+}
+
+func (c *cpuQuotaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *cpuQuotaCollector) Collect(ch chan<- prometheus.Metric) {
+	cores, ok := readCPUQuota(c.path)
+	if !ok {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, cores)
+}
+
+// readCPUQuota parses a cgroup v2 cpu.max file ("<quota> <period>" or "max <period>")
+// and returns the quota in cores. It reports false when the file is missing or unlimited.
+func readCPUQuota(path string) (float64, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, false
+	}
+
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || quota <= 0 {
+		return 0, false
+	}
+
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+
+	return quota / period, true
+}
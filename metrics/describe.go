@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// MetricDescriptor describes one exported metric family.
+type MetricDescriptor struct {
+	Name string `json:"name"`
+	// Type is the Prometheus type in lower case, e.g. "counter" or "histogram"
+	Type string `json:"type"`
+	Help string `json:"help,omitempty"`
+	// LabelKeys are the label names seen on any series of the family, sorted
+	LabelKeys []string `json:"label_keys,omitempty"`
+}
+
+// DescribeMetrics returns the metric families currently exported on /metrics, ordered
+// by name, e.g. to generate a metrics catalog. Instruments that haven't recorded
+// anything yet are not included. A failing collector is logged and its families skipped.
+func (p *Provider) DescribeMetrics() []MetricDescriptor {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		slog.Warn("Gathering metrics for description was incomplete", "error", err)
+	}
+
+	descriptors := make([]MetricDescriptor, 0, len(families))
+	for _, family := range families {
+		var labelKeys []string
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				labelKeys = append(labelKeys, label.GetName())
+			}
+		}
+		slices.Sort(labelKeys)
+
+		descriptors = append(
+			descriptors, MetricDescriptor{
+				Name:      family.GetName(),
+				Type:      strings.ToLower(family.GetType().String()),
+				Help:      family.GetHelp(),
+				LabelKeys: slices.Compact(labelKeys),
+			},
+		)
+	}
+
+	// Gather already sorts by name, but wrapping gatherers may not preserve it
+	slices.SortFunc(descriptors, func(a, b MetricDescriptor) int { return strings.Compare(a.Name, b.Name) })
+
+	return descriptors
+}
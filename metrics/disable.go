@@ -0,0 +1,44 @@
+package metrics
+
+import sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+// disableInstruments drops every instrument whose name matches one of patterns, which
+// may use the SDK's "*" and "?" wildcards. A drop view alone isn't enough, since the SDK
+// still creates a stream for each other view matching the instrument, so views are
+// wrapped to skip disabled instruments as well.
+func disableInstruments(views []sdkmetric.View, patterns []string) []sdkmetric.View {
+	if len(patterns) == 0 {
+		return views
+	}
+
+	matchers := make([]namedHistogramPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		matchers = append(matchers, newNamedHistogramPattern(pattern, nil))
+	}
+	disabled := func(name string) bool {
+		for _, matcher := range matchers {
+			if matcher.matches(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	wrapped := make([]sdkmetric.View, 0, len(views)+1)
+	wrapped = append(wrapped, func(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		if !disabled(instrument.Name) {
+			return sdkmetric.Stream{}, false
+		}
+		return sdkmetric.Stream{Aggregation: sdkmetric.AggregationDrop{}}, true
+	})
+	for _, view := range views {
+		wrapped = append(wrapped, func(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+			if disabled(instrument.Name) {
+				return sdkmetric.Stream{}, false
+			}
+			return view(instrument)
+		})
+	}
+
+	return wrapped
+}
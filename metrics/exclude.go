@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+)
+
+// excludingGatherer drops metric families by name before they are encoded.
+// It is an escape hatch for shared registries whose registrations we don't control.
+type excludingGatherer struct {
+	gatherer prometheus.Gatherer
+	excluded map[string]struct{}
+}
+
+// newExcludingGatherer wraps gatherer so that families named in excludedNames are dropped.
+// Returns gatherer unchanged when there is nothing to exclude.
+func newExcludingGatherer(gatherer prometheus.Gatherer, excludedNames []string) prometheus.Gatherer {
+	if len(excludedNames) == 0 {
+		return gatherer
+	}
+
+	excluded := make(map[string]struct{}, len(excludedNames))
+	for _, name := range excludedNames {
+		excluded[name] = struct{}{}
+	}
+
+	return &excludingGatherer{
+		gatherer: gatherer,
+		excluded: excluded,
+	}
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *excludingGatherer) Gather() ([]*prometheusClient.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+
+	filtered := families[:0]
+	for _, family := range families {
+		if _, ok := g.excluded[family.GetName()]; ok {
+			continue
+		}
+		filtered = append(filtered, family)
+	}
+
+	return filtered, err
+}
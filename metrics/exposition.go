@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// acceptForExpositionFormat maps an ExpositionFormat to the Accept header that forces it,
+// or "" to leave content negotiation to promhttp.
+func acceptForExpositionFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return "", nil
+	case "text":
+		return string(expfmt.NewFormat(expfmt.TypeTextPlain)), nil
+	case "protobuf":
+		return string(expfmt.NewFormat(expfmt.TypeProtoDelim)), nil
+	default:
+		return "", fmt.Errorf("%w %q: must be \"text\" or \"protobuf\"", ErrInvalidExpositionFormat, format)
+	}
+}
+
+// forceExpositionFormat overrides each request's Accept header with accept, so promhttp
+// answers in that format whatever the scraper asked for.
+func forceExpositionFormat(handler http.Handler, accept string) http.Handler {
+	if accept == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", accept)
+		handler.ServeHTTP(writer, req)
+	})
+}
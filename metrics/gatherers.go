@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+)
+
+// mergedGatherer gathers the provider's registry together with the gatherers added via
+// AddGatherer, merging them like prometheus.Gatherers.
+type mergedGatherer struct {
+	mutex     sync.RWMutex
+	gatherers prometheus.Gatherers
+}
+
+func newMergedGatherer(base prometheus.Gatherer) *mergedGatherer {
+	return &mergedGatherer{gatherers: prometheus.Gatherers{base}}
+}
+
+func (m *mergedGatherer) add(gatherer prometheus.Gatherer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.gatherers = append(slices.Clip(m.gatherers), gatherer)
+}
+
+func (m *mergedGatherer) Gather() ([]*prometheusClient.MetricFamily, error) {
+	m.mutex.RLock()
+	gatherers := m.gatherers
+	m.mutex.RUnlock()
+
+	return gatherers.Gather()
+}
+
+// AddGatherer serves gatherer's metrics on /metrics alongside the provider's own, e.g. the
+// application's own prometheus.Registry or a registry fed by other local processes. They pass
+// through the same exclusions, renames and global labels, and are included in remote write
+// and Pushgateway pushes. As with prometheus.Gatherers, a family gathered with a different
+// type or help on both sides, or a duplicate series, fails the scrape.
+func (p *Provider) AddGatherer(gatherer prometheus.Gatherer) {
+	p.merged.add(gatherer)
+}
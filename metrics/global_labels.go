@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+)
+
+// globalLabels adds a set of labels, changeable at runtime, to every series when metrics
+// are gathered. Series that already have a label of the same name keep their own value.
+type globalLabels struct {
+	mutex  sync.RWMutex
+	labels map[string]string
+}
+
+func newGlobalLabels(initial map[string]string) (*globalLabels, error) {
+	labels := &globalLabels{labels: make(map[string]string, len(initial))}
+	for name, value := range initial {
+		if err := labels.set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return labels, nil
+}
+
+func (g *globalLabels) set(name string, value string) error {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("%w %q: must be non-empty and not start with __", ErrInvalidGlobalLabel, name)
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.labels[name] = value
+	return nil
+}
+
+func (g *globalLabels) delete(name string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.labels, name)
+}
+
+func (g *globalLabels) snapshot() map[string]string {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return maps.Clone(g.labels)
+}
+
+// wrap returns gatherer with the current global labels added to every series.
+func (g *globalLabels) wrap(gatherer prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(
+		func() ([]*prometheusClient.MetricFamily, error) {
+			families, err := gatherer.Gather()
+
+			labels := g.snapshot()
+			if len(labels) == 0 {
+				return families, err
+			}
+
+			for _, family := range families {
+				for _, metric := range family.GetMetric() {
+					metric.Label = withLabels(metric.GetLabel(), labels)
+				}
+			}
+
+			return families, err
+		},
+	)
+}
+
+func withLabels(pairs []*prometheusClient.LabelPair, labels map[string]string) []*prometheusClient.LabelPair {
+	for name, value := range labels {
+		exists := slices.ContainsFunc(
+			pairs, func(pair *prometheusClient.LabelPair) bool {
+				return pair.GetName() == name
+			},
+		)
+		if !exists {
+			pairs = append(pairs, &prometheusClient.LabelPair{Name: &name, Value: &value})
+		}
+	}
+
+	// Encoders expect label pairs sorted by name
+	slices.SortFunc(
+		pairs, func(a, b *prometheusClient.LabelPair) int {
+			return strings.Compare(a.GetName(), b.GetName())
+		},
+	)
+	return pairs
+}
+
+// SetGlobalLabel adds name=value to every series served by /metrics, remote write and
+// Pushgateway, e.g. a shard id or leader role known only after startup. Setting an
+// existing name replaces its value.
+//
+// The label is applied when metrics are gathered, not when they are recorded, so it is
+// not a recorded attribute: every series, including values aggregated before the call,
+// is reported under the current value from the next gather on, and a change never splits
+// a series in two. OTLP export is unaffected; use resource attributes for it.
+func (p *Provider) SetGlobalLabel(name string, value string) error {
+	return p.globalLabels.set(name, value)
+}
+
+// DeleteGlobalLabel stops adding the named global label.
+func (p *Provider) DeleteGlobalLabel(name string) {
+	p.globalLabels.delete(name)
+}
@@ -1,43 +1,230 @@
 package metrics
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
 	"github.com/domesama/doakes/config"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
+// ErrInvalidHistogramBoundaries is returned when histogram boundaries are not strictly
+// increasing finite numbers.
+var ErrInvalidHistogramBoundaries = errors.New("invalid histogram boundaries")
+
 // CreateHistogramViews creates OpenTelemetry metric views for histogram configuration.
 // Named patterns (e.g., "*_ns") get their specific boundaries, all others use defaults.
-func CreateHistogramViews(metricsConfig config.MetricsConfig) []sdkmetric.View {
+//
+// Boundaries must be strictly increasing and finite, or ErrInvalidHistogramBoundaries is returned.
+// With SortHistogramBoundaries set, they are sorted and deduplicated first.
+// DefaultHistogramPreset, when set, replaces DefaultHistogramBoundaries; an unknown preset
+// also returns ErrInvalidHistogramBoundaries.
+// With OmitDefaultHistogramView set, no catch-all view is created for DefaultHistogramBoundaries.
+// With PreferHistogramAdvice set, the defaults come from HistogramAggregationSelector instead.
+func CreateHistogramViews(metricsConfig config.MetricsConfig) ([]sdkmetric.View, error) {
 	var views []sdkmetric.View
 
-	namedHistogramViews := createNamedHistogramViews(metricsConfig.HistogramBoundariesByName)
+	namedHistogramViews, err := createNamedHistogramViews(
+		metricsConfig.HistogramBoundariesByName,
+		metricsConfig.SortHistogramBoundaries,
+	)
+	if err != nil {
+		return nil, err
+	}
 	views = append(views, namedHistogramViews...)
 
-	defaultHistogramView := createDefaultHistogramView(metricsConfig.DefaultHistogramBoundaries)
+	if metricsConfig.OmitDefaultHistogramView || metricsConfig.PreferHistogramAdvice {
+		return views, nil
+	}
+
+	defaultBoundaries, err := defaultHistogramBoundaries(metricsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultHistogramView := createDefaultHistogramView(defaultBoundaries)
 	views = append(views, defaultHistogramView)
 
-	return views
+	return views, nil
 }
 
-func createNamedHistogramViews(boundariesByName map[string][]float64) []sdkmetric.View {
-	var views []sdkmetric.View
+// HistogramAggregationSelector returns the reader aggregation selector for
+// PreferHistogramAdvice, or nil when it is not set (or OmitDefaultHistogramView is).
+// Boundaries from a reader's selector apply only to histograms without
+// ExplicitBucketBoundaries advice and without a matching view, which gives the precedence
+// HistogramBoundariesByName > instrument advice > default boundaries.
+func HistogramAggregationSelector(metricsConfig config.MetricsConfig) (sdkmetric.AggregationSelector, error) {
+	if !metricsConfig.PreferHistogramAdvice || metricsConfig.OmitDefaultHistogramView {
+		return nil, nil
+	}
+
+	defaultBoundaries, err := defaultHistogramBoundaries(metricsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+		if kind == sdkmetric.InstrumentKindHistogram {
+			return sdkmetric.AggregationExplicitBucketHistogram{Boundaries: defaultBoundaries}
+		}
+		return sdkmetric.DefaultAggregationSelector(kind)
+	}, nil
+}
+
+// defaultHistogramBoundaries resolves DefaultHistogramPreset or DefaultHistogramBoundaries.
+func defaultHistogramBoundaries(metricsConfig config.MetricsConfig) ([]float64, error) {
+	defaultBoundaries := metricsConfig.DefaultHistogramBoundaries
+	if metricsConfig.DefaultHistogramPreset != "" {
+		presetBoundaries, ok := config.HistogramPresetBoundaries(metricsConfig.DefaultHistogramPreset)
+		if !ok {
+			return nil, fmt.Errorf(
+				"%w: unknown preset %q", ErrInvalidHistogramBoundaries, metricsConfig.DefaultHistogramPreset,
+			)
+		}
+		defaultBoundaries = presetBoundaries
+	}
+
+	return prepareBoundaries("default", defaultBoundaries, metricsConfig.SortHistogramBoundaries)
+}
+
+// NormalizeHistogramBoundaries returns a sorted copy of boundaries with duplicates removed.
+func NormalizeHistogramBoundaries(boundaries []float64) []float64 {
+	normalized := slices.Clone(boundaries)
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+func prepareBoundaries(name string, boundaries []float64, normalize bool) ([]float64, error) {
+	if normalize {
+		boundaries = NormalizeHistogramBoundaries(boundaries)
+	}
+
+	if err := validateBoundaries(boundaries); err != nil {
+		return nil, fmt.Errorf("%w for %q: %w", ErrInvalidHistogramBoundaries, name, err)
+	}
+
+	return boundaries, nil
+}
+
+// validateBoundaries checks what OTel requires: finite and strictly increasing.
+// Negative boundaries are allowed, since OTel permits them for signed measurements.
+func validateBoundaries(boundaries []float64) error {
+	for i, boundary := range boundaries {
+		if math.IsNaN(boundary) || math.IsInf(boundary, 0) {
+			return fmt.Errorf("boundary %v at index %d is not finite", boundary, i)
+		}
+
+		if i > 0 && boundary <= boundaries[i-1] {
+			return fmt.Errorf(
+				"boundary %v at index %d is not greater than previous boundary %v",
+				boundary, i, boundaries[i-1],
+			)
+		}
+	}
+
+	return nil
+}
+
+// createNamedHistogramViews returns a single view over all named patterns, so an
+// instrument matched by several patterns gets one stream from the most specific of them.
+// An exact name beats any wildcard, and among wildcards the one with the most literal
+// characters wins, so "process.runtime.go.gc.pause_ns" overrides the default "*_ns".
+func createNamedHistogramViews(boundariesByName map[string][]float64, normalize bool) ([]sdkmetric.View, error) {
+	if len(boundariesByName) == 0 {
+		return nil, nil
+	}
 
+	patterns := make([]namedHistogramPattern, 0, len(boundariesByName))
 	for metricNamePattern, boundaries := range boundariesByName {
-		view := sdkmetric.NewView(
-			sdkmetric.Instrument{
-				Name: metricNamePattern,
-				Kind: sdkmetric.InstrumentKindHistogram,
-			},
-			sdkmetric.Stream{
+		boundaries, err := prepareBoundaries(metricNamePattern, boundaries, normalize)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, newNamedHistogramPattern(metricNamePattern, boundaries))
+	}
+
+	slices.SortFunc(patterns, compareNamedHistogramPatterns)
+
+	view := func(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		if instrument.Kind != sdkmetric.InstrumentKindHistogram {
+			return sdkmetric.Stream{}, false
+		}
+
+		for _, pattern := range patterns {
+			if !pattern.matches(instrument.Name) {
+				continue
+			}
+
+			return sdkmetric.Stream{
+				Name:        instrument.Name,
+				Description: instrument.Description,
+				Unit:        instrument.Unit,
 				Aggregation: sdkmetric.AggregationExplicitBucketHistogram{
-					Boundaries: boundaries,
+					Boundaries: pattern.boundaries,
 				},
-			},
-		)
-		views = append(views, view)
+			}, true
+		}
+
+		return sdkmetric.Stream{}, false
+	}
+
+	return []sdkmetric.View{view}, nil
+}
+
+// namedHistogramPattern is one HistogramBoundariesByName entry. Wildcards follow the
+// SDK's view semantics: "*" matches any run of characters and "?" matches one.
+type namedHistogramPattern struct {
+	pattern    string
+	wildcard   *regexp.Regexp
+	boundaries []float64
+}
+
+func newNamedHistogramPattern(pattern string, boundaries []float64) namedHistogramPattern {
+	named := namedHistogramPattern{pattern: pattern, boundaries: boundaries}
+	if strings.ContainsAny(pattern, "*?") {
+		expression := regexp.QuoteMeta(pattern)
+		expression = strings.ReplaceAll(expression, `\*`, ".*")
+		expression = strings.ReplaceAll(expression, `\?`, ".")
+		named.wildcard = regexp.MustCompile("^" + expression + "$")
+	}
+
+	return named
+}
+
+func (p namedHistogramPattern) matches(name string) bool {
+	if p.wildcard == nil {
+		return p.pattern == name
+	}
+
+	return p.wildcard.MatchString(name)
+}
+
+// literalLength counts the non-wildcard characters, the measure of specificity.
+func (p namedHistogramPattern) literalLength() int {
+	return len(p.pattern) - strings.Count(p.pattern, "*") - strings.Count(p.pattern, "?")
+}
+
+// compareNamedHistogramPatterns orders exact names first, then wildcards by
+// descending literal length, then lexically so the order is deterministic.
+func compareNamedHistogramPatterns(a, b namedHistogramPattern) int {
+	if (a.wildcard == nil) != (b.wildcard == nil) {
+		if a.wildcard == nil {
+			return -1
+		}
+		return 1
+	}
+
+	if c := cmp.Compare(b.literalLength(), a.literalLength()); c != 0 {
+		return c
 	}
 
-	return views
+	return strings.Compare(a.pattern, b.pattern)
 }
 
 func createDefaultHistogramView(boundaries []float64) sdkmetric.View {
@@ -0,0 +1,327 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/domesama/doakes/config"
+	"github.com/domesama/doakes/testutil"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestCreateHistogramViewsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		boundaries []float64
+		sort       bool
+		valid      bool
+	}{
+		{name: "increasing", boundaries: []float64{1, 5, 10}, valid: true},
+		{name: "negative increasing", boundaries: []float64{-10, 0, 10}, valid: true},
+		{name: "empty", boundaries: nil, valid: true},
+		{name: "out of order", boundaries: []float64{1, 10, 5}, valid: false},
+		{name: "duplicate", boundaries: []float64{1, 5, 5}, valid: false},
+		{name: "NaN", boundaries: []float64{1, math.NaN()}, valid: false},
+		{name: "infinite", boundaries: []float64{1, math.Inf(1)}, valid: false},
+		{name: "out of order with sort", boundaries: []float64{10, 1, 5, 5}, sort: true, valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				metricsConfig := config.MetricsConfig{
+					HistogramBoundariesByName: map[string][]float64{"*_ms": tt.boundaries},
+					SortHistogramBoundaries:   tt.sort,
+				}
+
+				_, err := CreateHistogramViews(metricsConfig)
+				if tt.valid && err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if !tt.valid && !errors.Is(err, ErrInvalidHistogramBoundaries) {
+					t.Fatalf("expected ErrInvalidHistogramBoundaries, got %v", err)
+				}
+			},
+		)
+	}
+}
+
+func TestDefaultHistogramBoundariesAreValid(t *testing.T) {
+	if _, err := CreateHistogramViews(config.DefaultMetricsConfig()); err != nil {
+		t.Fatalf("default boundaries are invalid: %v", err)
+	}
+}
+
+func TestDefaultHistogramPreset(t *testing.T) {
+	for _, preset := range []string{
+		config.HistogramPresetLatencyMs,
+		config.HistogramPresetSizeBytes,
+		config.HistogramPresetDurationNs,
+	} {
+		if _, err := CreateHistogramViews(config.MetricsConfig{DefaultHistogramPreset: preset}); err != nil {
+			t.Fatalf("preset %q is invalid: %v", preset, err)
+		}
+	}
+
+	_, err := CreateHistogramViews(config.MetricsConfig{DefaultHistogramPreset: "latency_hours"})
+	if !errors.Is(err, ErrInvalidHistogramBoundaries) {
+		t.Fatalf("expected ErrInvalidHistogramBoundaries, got %v", err)
+	}
+}
+
+func TestNormalizeHistogramBoundaries(t *testing.T) {
+	boundaries := []float64{10, 1, 5, 5}
+
+	normalized := NormalizeHistogramBoundaries(boundaries)
+
+	if !slices.Equal(normalized, []float64{1, 5, 10}) {
+		t.Errorf("expected [1 5 10], got %v", normalized)
+	}
+	if !slices.Equal(boundaries, []float64{10, 1, 5, 5}) {
+		t.Errorf("expected input to be left untouched, got %v", boundaries)
+	}
+}
+
+func TestOmitDefaultHistogramViewRespectsAdvice(t *testing.T) {
+	bucketBounds := func(t *testing.T, omitDefaultView bool) []float64 {
+		metricsConfig := config.DefaultMetricsConfig()
+		metricsConfig.OmitDefaultHistogramView = omitDefaultView
+
+		provider, err := NewProvider(resource.Default(), metricsConfig)
+		if err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+		defer provider.Cleanup()
+
+		histogram, err := provider.GetMeter().Int64Histogram(
+			"advised_latency",
+			metric.WithExplicitBucketBoundaries(1, 2, 3),
+		)
+		if err != nil {
+			t.Fatalf("failed to create histogram: %v", err)
+		}
+		histogram.Record(context.Background(), 2)
+
+		scraped := testutil.FlushAndGather(t, provider).GetSingle(t, "advised_latency", nil)
+		if scraped == nil {
+			t.Fatal("advised_latency not found")
+		}
+
+		var bounds []float64
+		for _, bucket := range scraped.GetHistogram().GetBucket() {
+			bounds = append(bounds, bucket.GetUpperBound())
+		}
+		return bounds
+	}
+
+	if bounds := bucketBounds(t, true); !slices.Equal(bounds, []float64{1, 2, 3}) {
+		t.Errorf("expected advised buckets [1 2 3], got %v", bounds)
+	}
+
+	defaults := config.DefaultMetricsConfig().DefaultHistogramBoundaries
+	if bounds := bucketBounds(t, false); !slices.Equal(bounds, defaults) {
+		t.Errorf("expected default buckets %v, got %v", defaults, bounds)
+	}
+}
+
+func TestRuntimeHistogramBoundariesByName(t *testing.T) {
+	t.Setenv("OTEL_GO_X_DEPRECATED_RUNTIME_METRICS", "true")
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.HistogramBoundariesByName[RuntimeGCPauseHistogramName] = []float64{1000, 10000}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	runtime.GC()
+
+	family := testutil.FlushAndGather(t, provider).Families()["process_runtime_go_gc_pause_ns_nanoseconds"]
+	if family == nil || len(family.GetMetric()) != 1 {
+		t.Fatalf("expected a single GC pause histogram stream, got %v", family)
+	}
+
+	var bounds []float64
+	for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+		bounds = append(bounds, bucket.GetUpperBound())
+	}
+	if !slices.Equal(bounds, []float64{1000, 10000}) {
+		t.Errorf("expected exact name to override \"*_ns\" with [1000 10000], got %v", bounds)
+	}
+}
+
+func TestNamedHistogramPatternPrecedence(t *testing.T) {
+	views, err := createNamedHistogramViews(map[string][]float64{
+		"*":          {1},
+		"*_ns":       {2},
+		"gc_*_ns":    {3},
+		"gc_wait_ns": {4},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]float64{
+		"gc_wait_ns":  4,
+		"gc_pause_ns": 3,
+		"request_ns":  2,
+		"request_ms":  1,
+	}
+	for name, expected := range tests {
+		stream, ok := views[0](sdkmetric.Instrument{Name: name, Kind: sdkmetric.InstrumentKindHistogram})
+		if !ok {
+			t.Errorf("%s: expected a match", name)
+			continue
+		}
+
+		aggregation := stream.Aggregation.(sdkmetric.AggregationExplicitBucketHistogram)
+		if !slices.Equal(aggregation.Boundaries, []float64{expected}) {
+			t.Errorf("%s: expected boundaries [%v], got %v", name, expected, aggregation.Boundaries)
+		}
+	}
+}
+
+// Regression test: named views run before the default histogram view and the catch-all
+// view, so their boundaries must be the ones on the scrape output.
+func TestNamedHistogramBoundariesOnScrape(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.HistogramBoundariesByName["*_ms"] = []float64{1, 2}
+	metricsConfig.HistogramBoundariesByName["my_metric_ms"] = []float64{7, 8}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	for _, name := range []string{"my_metric_ms", "queue_wait_ms", "other_latency"} {
+		histogram, err := provider.GetMeter().Float64Histogram(name)
+		if err != nil {
+			t.Fatalf("failed to create histogram %s: %v", name, err)
+		}
+		histogram.Record(context.Background(), 1.5)
+	}
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	tests := map[string][]float64{
+		"my_metric_ms":  {7, 8},
+		"queue_wait_ms": {1, 2},
+		"other_latency": metricsConfig.DefaultHistogramBoundaries,
+	}
+	for name, expected := range tests {
+		histogram := scraped.GetSingle(t, name, nil)
+		if histogram == nil {
+			t.Fatalf("%s not found on the scrape output", name)
+		}
+
+		var bounds []float64
+		for _, bucket := range histogram.GetHistogram().GetBucket() {
+			bounds = append(bounds, bucket.GetUpperBound())
+		}
+		if !slices.Equal(bounds, expected) {
+			t.Errorf("%s: expected boundaries %v, got %v", name, expected, bounds)
+		}
+	}
+}
+
+func TestPreferHistogramAdvice(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.PreferHistogramAdvice = true
+	metricsConfig.HistogramBoundariesByName["configured_latency"] = []float64{100, 200}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	record := func(name string, options ...metric.Float64HistogramOption) {
+		histogram, err := provider.GetMeter().Float64Histogram(name, options...)
+		if err != nil {
+			t.Fatalf("failed to create histogram %s: %v", name, err)
+		}
+		histogram.Record(context.Background(), 2)
+	}
+	record("advised_latency", metric.WithExplicitBucketBoundaries(1, 2, 3))
+	record("configured_latency", metric.WithExplicitBucketBoundaries(1, 2, 3))
+	record("plain_latency")
+
+	scraped := testutil.FlushAndGather(t, provider)
+	bucketBounds := func(name string) []float64 {
+		histogram := scraped.GetSingle(t, name, nil)
+		if histogram == nil {
+			t.Fatalf("%s not found", name)
+		}
+
+		var bounds []float64
+		for _, bucket := range histogram.GetHistogram().GetBucket() {
+			bounds = append(bounds, bucket.GetUpperBound())
+		}
+		return bounds
+	}
+
+	if bounds := bucketBounds("advised_latency"); !slices.Equal(bounds, []float64{1, 2, 3}) {
+		t.Errorf("expected advice to beat the defaults, got %v", bounds)
+	}
+	if bounds := bucketBounds("configured_latency"); !slices.Equal(bounds, []float64{100, 200}) {
+		t.Errorf("expected per-name config to beat advice, got %v", bounds)
+	}
+	if bounds := bucketBounds("plain_latency"); !slices.Equal(bounds, metricsConfig.DefaultHistogramBoundaries) {
+		t.Errorf("expected default boundaries without advice, got %v", bounds)
+	}
+}
+
+func TestNamedHistogramViewWithCatchAllView(t *testing.T) {
+	for _, omitCatchAllView := range []bool{false, true} {
+		t.Run(fmt.Sprintf("omit=%t", omitCatchAllView), func(t *testing.T) {
+			metricsConfig := config.DefaultMetricsConfig()
+			metricsConfig.OmitCatchAllView = omitCatchAllView
+			metricsConfig.HistogramBoundariesByName["queue_wait"] = []float64{5, 10}
+
+			provider, err := NewProvider(resource.Default(), metricsConfig)
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+			defer provider.Cleanup()
+
+			histogram, err := provider.GetMeter().Float64Histogram("queue_wait")
+			if err != nil {
+				t.Fatalf("failed to create histogram: %v", err)
+			}
+			histogram.Record(context.Background(), 7)
+
+			counter, err := provider.GetMeter().Int64Counter("queue_items")
+			if err != nil {
+				t.Fatalf("failed to create counter: %v", err)
+			}
+			counter.Add(context.Background(), 1)
+
+			scraped := testutil.FlushAndGather(t, provider)
+
+			series := scraped.Get("queue_wait", nil)
+			if len(series) != 1 {
+				t.Fatalf("expected a single queue_wait series, got %d", len(series))
+			}
+			var bounds []float64
+			for _, bucket := range series[0].GetHistogram().GetBucket() {
+				bounds = append(bounds, bucket.GetUpperBound())
+			}
+			if !slices.Equal(bounds, []float64{5, 10}) {
+				t.Errorf("expected per-name boundaries [5 10], got %v", bounds)
+			}
+
+			if scraped.GetSingle(t, "queue_items_total", nil) == nil {
+				t.Error("expected queue_items_total to be exported")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// The Must* helpers create instruments that always carry a description, which becomes
+// the Prometheus HELP text, and an optional UCUM unit (e.g. "s", "ms" or "By") that
+// the exporter appends as a suffix such as _seconds or _bytes. Empty unit omits it.
+// They panic if the instrument cannot be created, which only happens for invalid names,
+// so call them during initialization.
+
+// MustInt64Counter creates an Int64Counter with the given description and unit.
+func MustInt64Counter(
+	meter metric.Meter, name, description, unit string, options ...metric.Int64CounterOption,
+) metric.Int64Counter {
+	options = append([]metric.Int64CounterOption{metric.WithDescription(description), metric.WithUnit(unit)}, options...)
+	return must(meter.Int64Counter(name, options...))
+}
+
+// MustFloat64Counter creates a Float64Counter with the given description and unit.
+func MustFloat64Counter(
+	meter metric.Meter, name, description, unit string, options ...metric.Float64CounterOption,
+) metric.Float64Counter {
+	options = append([]metric.Float64CounterOption{metric.WithDescription(description), metric.WithUnit(unit)}, options...)
+	return must(meter.Float64Counter(name, options...))
+}
+
+// MustInt64UpDownCounter creates an Int64UpDownCounter with the given description and unit.
+func MustInt64UpDownCounter(
+	meter metric.Meter, name, description, unit string, options ...metric.Int64UpDownCounterOption,
+) metric.Int64UpDownCounter {
+	options = append(
+		[]metric.Int64UpDownCounterOption{metric.WithDescription(description), metric.WithUnit(unit)}, options...,
+	)
+	return must(meter.Int64UpDownCounter(name, options...))
+}
+
+// MustFloat64Histogram creates a Float64Histogram with the given description and unit.
+func MustFloat64Histogram(
+	meter metric.Meter, name, description, unit string, options ...metric.Float64HistogramOption,
+) metric.Float64Histogram {
+	options = append(
+		[]metric.Float64HistogramOption{metric.WithDescription(description), metric.WithUnit(unit)}, options...,
+	)
+	return must(meter.Float64Histogram(name, options...))
+}
+
+// MustFloat64Gauge creates a Float64Gauge with the given description and unit.
+func MustFloat64Gauge(
+	meter metric.Meter, name, description, unit string, options ...metric.Float64GaugeOption,
+) metric.Float64Gauge {
+	options = append([]metric.Float64GaugeOption{metric.WithDescription(description), metric.WithUnit(unit)}, options...)
+	return must(meter.Float64Gauge(name, options...))
+}
+
+func must[T any](instrument T, err error) T {
+	if err != nil {
+		panic(fmt.Sprintf("failed to create instrument: %v", err))
+	}
+	return instrument
+}
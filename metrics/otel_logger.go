@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+)
+
+// forwardOTelLogs routes the OTel global logger to slog. OTel's default logger only prints
+// errors, so SDK warnings such as "duplicate metric stream definitions", logged when two
+// instruments share a name but not a kind, unit or description, would otherwise be lost.
+func forwardOTelLogs() {
+	otel.SetLogger(logr.FromSlogHandler(otelLogHandler{}))
+}
+
+// otelLogHandler maps OTel's logr verbosities to slog levels: errors stay errors, V(1)
+// warnings become warnings, and the chatty V(4) info and V(8) debug messages become debug.
+// It writes to slog's default handler as of each record, so a logger installed after
+// NewProvider is still used.
+type otelLogHandler struct {
+	handler slog.Handler
+}
+
+func (h otelLogHandler) target() slog.Handler {
+	if h.handler != nil {
+		return h.handler
+	}
+	return slog.Default().Handler().WithAttrs([]slog.Attr{slog.String("module", "otel")})
+}
+
+func (h otelLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.target().Enabled(ctx, otelLogLevel(level))
+}
+
+func (h otelLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.Level = otelLogLevel(record.Level)
+	return h.target().Handle(ctx, record)
+}
+
+func (h otelLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return otelLogHandler{handler: h.target().WithAttrs(attrs)}
+}
+
+func (h otelLogHandler) WithGroup(name string) slog.Handler {
+	return otelLogHandler{handler: h.target().WithGroup(name)}
+}
+
+// otelLogLevel converts the level logr derives from a verbosity (-V) into a slog level.
+func otelLogLevel(level slog.Level) slog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return slog.LevelError
+	case level >= 0:
+		return slog.LevelInfo
+	case level > -4:
+		return slog.LevelWarn
+	default:
+		return slog.LevelDebug
+	}
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/domesama/doakes/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var instrumentKindsByName = map[string]sdkmetric.InstrumentKind{
+	"counter":                    sdkmetric.InstrumentKindCounter,
+	"up_down_counter":            sdkmetric.InstrumentKindUpDownCounter,
+	"histogram":                  sdkmetric.InstrumentKindHistogram,
+	"gauge":                      sdkmetric.InstrumentKindGauge,
+	"observable_counter":         sdkmetric.InstrumentKindObservableCounter,
+	"observable_up_down_counter": sdkmetric.InstrumentKindObservableUpDownCounter,
+	"observable_gauge":           sdkmetric.InstrumentKindObservableGauge,
+}
+
+// newOTLPReaders returns periodic readers exporting to the configured OTLP/HTTP endpoint.
+// The meter provider's shutdown flushes them, so the last interval is exported on Cleanup.
+//
+// A temporality selector only sees the instrument kind, so instruments listed in
+// TemporalityByInstrument are exported by an extra reader per temporality that keeps just
+// them, while the main reader leaves them out.
+func newOTLPReaders(
+	otlpConfig config.OTLPConfig,
+	aggregationSelector sdkmetric.AggregationSelector,
+) ([]sdkmetric.Reader, error) {
+	if otlpConfig.Interval <= 0 {
+		return nil, fmt.Errorf("%w: interval must be positive", ErrInvalidOTLP)
+	}
+
+	selector, err := otlpTemporalitySelector(otlpConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	byInstrument := make(map[string]metricdata.Temporality, len(otlpConfig.TemporalityByInstrument))
+	used := make(map[metricdata.Temporality]bool, 2)
+	for name, value := range otlpConfig.TemporalityByInstrument {
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty instrument name", ErrInvalidOTLP)
+		}
+
+		temporality, err := parseTemporality(value)
+		if err != nil {
+			return nil, err
+		}
+		byInstrument[name] = temporality
+		used[temporality] = true
+	}
+
+	var keepUnlisted func(name string) bool
+	if len(byInstrument) > 0 {
+		keepUnlisted = func(name string) bool {
+			_, listed := byInstrument[name]
+			return !listed
+		}
+	}
+	reader, err := newOTLPReader(otlpConfig, aggregationSelector, selector, keepUnlisted)
+	if err != nil {
+		return nil, err
+	}
+	readers := []sdkmetric.Reader{reader}
+
+	for _, temporality := range []metricdata.Temporality{
+		metricdata.CumulativeTemporality, metricdata.DeltaTemporality,
+	} {
+		if !used[temporality] {
+			continue
+		}
+
+		reader, err := newOTLPReader(
+			otlpConfig, aggregationSelector,
+			func(sdkmetric.InstrumentKind) metricdata.Temporality { return temporality },
+			func(name string) bool {
+				listedTemporality, listed := byInstrument[name]
+				return listed && listedTemporality == temporality
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
+
+	return readers, nil
+}
+
+// newOTLPReader returns a periodic reader exporting the metrics whose name keep accepts,
+// or every metric when keep is nil.
+func newOTLPReader(
+	otlpConfig config.OTLPConfig,
+	aggregationSelector sdkmetric.AggregationSelector,
+	temporalitySelector sdkmetric.TemporalitySelector,
+	keep func(name string) bool,
+) (sdkmetric.Reader, error) {
+	options := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpointURL(otlpConfig.Endpoint),
+		otlpmetrichttp.WithTemporalitySelector(temporalitySelector),
+	}
+	if otlpConfig.Timeout > 0 {
+		options = append(options, otlpmetrichttp.WithTimeout(otlpConfig.Timeout))
+	}
+	if len(otlpConfig.Headers) > 0 {
+		options = append(options, otlpmetrichttp.WithHeaders(otlpConfig.Headers))
+	}
+	if aggregationSelector != nil {
+		options = append(options, otlpmetrichttp.WithAggregationSelector(aggregationSelector))
+	}
+
+	otlpExporter, err := otlpmetrichttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOTLP, err)
+	}
+
+	var exporter sdkmetric.Exporter = otlpExporter
+	if keep != nil {
+		exporter = &filteringExporter{Exporter: otlpExporter, keep: keep}
+	}
+
+	return sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(otlpConfig.Interval)), nil
+}
+
+// filteringExporter exports only the metrics whose name keep accepts. Views apply to every
+// reader of a meter provider, so per-reader selection has to happen at export.
+type filteringExporter struct {
+	sdkmetric.Exporter
+	keep func(name string) bool
+}
+
+// Export implements sdkmetric.Exporter. Collections left empty by the filter aren't sent.
+func (e *filteringExporter) Export(ctx context.Context, resourceMetrics *metricdata.ResourceMetrics) error {
+	filtered := metricdata.ResourceMetrics{Resource: resourceMetrics.Resource}
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		var kept []metricdata.Metrics
+		for _, metric := range scopeMetrics.Metrics {
+			if e.keep(metric.Name) {
+				kept = append(kept, metric)
+			}
+		}
+		if len(kept) > 0 {
+			filtered.ScopeMetrics = append(
+				filtered.ScopeMetrics, metricdata.ScopeMetrics{Scope: scopeMetrics.Scope, Metrics: kept},
+			)
+		}
+	}
+
+	if len(filtered.ScopeMetrics) == 0 {
+		return nil
+	}
+	return e.Exporter.Export(ctx, &filtered)
+}
+
+// otlpTemporalitySelector resolves Temporality and TemporalityByKind into a selector,
+// rejecting unknown kinds and temporalities up front.
+func otlpTemporalitySelector(otlpConfig config.OTLPConfig) (sdkmetric.TemporalitySelector, error) {
+	defaultTemporality, err := parseTemporality(otlpConfig.Temporality)
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := make(map[sdkmetric.InstrumentKind]metricdata.Temporality, len(otlpConfig.TemporalityByKind))
+	for name, value := range otlpConfig.TemporalityByKind {
+		kind, ok := instrumentKindsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown instrument kind %q", ErrInvalidOTLP, name)
+		}
+
+		temporality, err := parseTemporality(value)
+		if err != nil {
+			return nil, err
+		}
+		byKind[kind] = temporality
+	}
+
+	return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+		if temporality, ok := byKind[kind]; ok {
+			return temporality
+		}
+		return defaultTemporality
+	}, nil
+}
+
+func parseTemporality(value string) (metricdata.Temporality, error) {
+	switch value {
+	case "", "cumulative":
+		return metricdata.CumulativeTemporality, nil
+	case "delta":
+		return metricdata.DeltaTemporality, nil
+	default:
+		return 0, fmt.Errorf("%w: temporality %q must be cumulative or delta", ErrInvalidOTLP, value)
+	}
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/domesama/doakes/config"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	otlpmetrics "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// newOTLPCollector starts an OTLP/HTTP endpoint that records the exported metrics.
+func newOTLPCollector(t *testing.T) (string, <-chan *collectormetrics.ExportMetricsServiceRequest) {
+	exports := make(chan *collectormetrics.ExportMetricsServiceRequest, 16)
+
+	collector := httptest.NewServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				export := &collectormetrics.ExportMetricsServiceRequest{}
+				if err := proto.Unmarshal(body, export); err != nil {
+					writer.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				exports <- export
+				writer.Header().Set("Content-Type", "application/x-protobuf")
+				writer.WriteHeader(http.StatusOK)
+			},
+		),
+	)
+	t.Cleanup(collector.Close)
+
+	return collector.URL + "/v1/metrics", exports
+}
+
+// exportedTemporalities returns the temporality of every sum and histogram exported so far,
+// and how many times each metric was exported.
+func exportedTemporalities(
+	t *testing.T,
+	exports <-chan *collectormetrics.ExportMetricsServiceRequest,
+) (map[string]otlpmetrics.AggregationTemporality, map[string]int) {
+	temporalities := map[string]otlpmetrics.AggregationTemporality{}
+	counts := map[string]int{}
+	for {
+		select {
+		case export := <-exports:
+			for _, resourceMetrics := range export.GetResourceMetrics() {
+				for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+					for _, metric := range scopeMetrics.GetMetrics() {
+						counts[metric.GetName()]++
+						switch data := metric.GetData().(type) {
+						case *otlpmetrics.Metric_Sum:
+							temporalities[metric.GetName()] = data.Sum.GetAggregationTemporality()
+						case *otlpmetrics.Metric_Histogram:
+							temporalities[metric.GetName()] = data.Histogram.GetAggregationTemporality()
+						}
+					}
+				}
+			}
+		default:
+			if len(counts) == 0 {
+				t.Fatal("expected an OTLP export")
+			}
+			return temporalities, counts
+		}
+	}
+}
+
+func TestProviderOTLPTemporalityByKind(t *testing.T) {
+	endpoint, exports := newOTLPCollector(t)
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.OTLP = config.OTLPConfig{
+		Endpoint:          endpoint,
+		Interval:          time.Hour,
+		Timeout:           time.Second,
+		Temporality:       "cumulative",
+		TemporalityByKind: map[string]string{"histogram": "delta"},
+	}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	meter := provider.GetMeter()
+	counter, err := meter.Int64Counter("otlp_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	histogram, err := meter.Float64Histogram("otlp_request_duration")
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+	histogram.Record(context.Background(), 12)
+
+	// Cleanup flushes the periodic reader
+	provider.Cleanup()
+
+	temporalities, _ := exportedTemporalities(t, exports)
+	if got := temporalities["otlp_requests"]; got != otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Errorf("expected cumulative counter, got %v", got)
+	}
+	if got := temporalities["otlp_request_duration"]; got != otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+		t.Errorf("expected delta histogram, got %v", got)
+	}
+}
+
+func TestProviderOTLPTemporalityByInstrument(t *testing.T) {
+	endpoint, exports := newOTLPCollector(t)
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.OTLP = config.OTLPConfig{
+		Endpoint:          endpoint,
+		Interval:          time.Hour,
+		Timeout:           time.Second,
+		Temporality:       "cumulative",
+		TemporalityByKind: map[string]string{"counter": "delta"},
+		TemporalityByInstrument: map[string]string{
+			"otlp_request_duration": "delta",
+			"otlp_jobs":             "cumulative",
+		},
+	}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	meter := provider.GetMeter()
+	requests, err := meter.Int64Counter("otlp_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	jobs, err := meter.Int64Counter("otlp_jobs")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	requestDuration, err := meter.Float64Histogram("otlp_request_duration")
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+	jobDuration, err := meter.Float64Histogram("otlp_job_duration")
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+	requests.Add(context.Background(), 1)
+	jobs.Add(context.Background(), 1)
+	requestDuration.Record(context.Background(), 12)
+	jobDuration.Record(context.Background(), 3)
+
+	provider.Cleanup()
+
+	temporalities, counts := exportedTemporalities(t, exports)
+	expected := map[string]otlpmetrics.AggregationTemporality{
+		"otlp_requests":         otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+		"otlp_jobs":             otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		"otlp_request_duration": otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+		"otlp_job_duration":     otlpmetrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+	}
+	for name, want := range expected {
+		if got := temporalities[name]; got != want {
+			t.Errorf("expected %s to be %v, got %v", name, want, got)
+		}
+		if counts[name] != 1 {
+			t.Errorf("expected %s to be exported once, got %d", name, counts[name])
+		}
+	}
+}
+
+func TestProviderOTLPInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		otlpConfig config.OTLPConfig
+	}{
+		{name: "interval", otlpConfig: config.OTLPConfig{Endpoint: "http://localhost:1"}},
+		{
+			name:       "temporality",
+			otlpConfig: config.OTLPConfig{Endpoint: "http://localhost:1", Interval: time.Second, Temporality: "lowmemory"},
+		},
+		{
+			name: "instrument temporality",
+			otlpConfig: config.OTLPConfig{
+				Endpoint: "http://localhost:1", Interval: time.Second,
+				TemporalityByInstrument: map[string]string{"otlp_requests": "lowmemory"},
+			},
+		},
+		{
+			name: "instrument kind",
+			otlpConfig: config.OTLPConfig{
+				Endpoint: "http://localhost:1", Interval: time.Second,
+				TemporalityByKind: map[string]string{"summary": "delta"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				metricsConfig := config.DefaultMetricsConfig()
+				metricsConfig.OTLP = tt.otlpConfig
+
+				if _, err := NewProvider(resource.Default(), metricsConfig); !errors.Is(err, ErrInvalidOTLP) {
+					t.Fatalf("expected ErrInvalidOTLP, got %v", err)
+				}
+			},
+		)
+	}
+}
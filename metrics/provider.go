@@ -3,14 +3,19 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/domesama/doakes/config"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/otlptranslator"
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel"
 	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
@@ -20,60 +25,217 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 )
 
+// DefaultServiceNameFallback is the service name used when none is configured.
+const DefaultServiceNameFallback = "unknown-service"
+
+var (
+	serviceNameFallbackMutex sync.RWMutex
+	serviceNameFallback      = DefaultServiceNameFallback
+)
+
+var (
+	// ErrExporterInit is returned when the OTel Prometheus exporter cannot be created.
+	ErrExporterInit = errors.New("failed to create prometheus exporter")
+	// ErrRuntimeMetricsInit is returned when Go runtime metrics collection cannot be started.
+	ErrRuntimeMetricsInit = errors.New("failed to initialize runtime metrics")
+	// ErrBuildInfoInit is returned when the build info gauge cannot be registered.
+	ErrBuildInfoInit = errors.New("failed to register build info metric")
+	// ErrCPUQuotaInit is returned when the CPU quota gauge cannot be registered.
+	ErrCPUQuotaInit = errors.New("failed to register cpu quota metric")
+	// ErrInvalidRenameRule is returned when a rename rule's Match is not a valid regular expression.
+	ErrInvalidRenameRule = errors.New("invalid rename rule")
+	// ErrInvalidRemoteWrite is returned when remote write is enabled with an invalid configuration.
+	ErrInvalidRemoteWrite = errors.New("invalid remote write configuration")
+	// ErrInvalidPushgateway is returned when Pushgateway push is enabled with an invalid configuration.
+	ErrInvalidPushgateway = errors.New("invalid pushgateway configuration")
+	// ErrInvalidOTLP is returned when OTLP export is enabled with an invalid configuration.
+	ErrInvalidOTLP = errors.New("invalid otlp configuration")
+	// ErrInvalidGlobalLabel is returned when a global label name cannot be used.
+	ErrInvalidGlobalLabel = errors.New("invalid global label")
+	// ErrInvalidNameValidationScheme is returned when NameValidationScheme is not "", "legacy" or "utf8".
+	ErrInvalidNameValidationScheme = errors.New("invalid metric name validation scheme")
+	// ErrInvalidExpositionFormat is returned when ExpositionFormat is not "", "text" or "protobuf".
+	ErrInvalidExpositionFormat = errors.New("invalid exposition format")
+)
+
 // Provider manages the OpenTelemetry meter provider and Prometheus exporter.
 type Provider struct {
 	registry      *prometheus.Registry
 	exporter      *otelprom.Exporter
 	meterProvider *sdkmetric.MeterProvider
+	gatherer      prometheus.Gatherer
+	merged        *mergedGatherer
 	httpHandler   http.Handler
 	cleanupFuncs  []func()
 	serviceName   string
+	tenants       *tenantRegistries
+	globalLabels  *globalLabels
 }
 
 // NewProvider creates a new metrics provider with Prometheus export.
 // It configures histogram views, starts runtime metrics, and sets the global meter provider.
+// With ForwardOTelLogs set, it also forwards the OTel global logger to slog.
 func NewProvider(res *resource.Resource, metricsConfig config.MetricsConfig) (*Provider, error) {
+	metricRenamer, err := newRenamer(metricsConfig.RenameRules)
+	if err != nil {
+		return nil, err
+	}
+
+	if metricsConfig.RemoteWrite.URL != "" && metricsConfig.RemoteWrite.Interval <= 0 {
+		return nil, fmt.Errorf("%w: interval must be positive", ErrInvalidRemoteWrite)
+	}
+
+	if metricsConfig.Pushgateway.Interval < 0 {
+		return nil, fmt.Errorf("%w: interval must not be negative", ErrInvalidPushgateway)
+	}
+
+	labels, err := newGlobalLabels(metricsConfig.GlobalLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	exporterOptions, err := exporterOptionsFor(metricsConfig.NameValidationScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	accept, err := acceptForExpositionFormat(metricsConfig.ExpositionFormat)
+	if err != nil {
+		return nil, err
+	}
+
 	registry := createPrometheusRegistry(metricsConfig)
 
-	exporter, err := createOtelPrometheusExporter(registry)
+	if err := registerBuildInfo(registry, metricsConfig.BuildInfoMetricName, res); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBuildInfoInit, err)
+	}
+
+	if err := registerCPUQuota(registry, metricsConfig.CPUQuotaMetricName); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCPUQuotaInit, err)
+	}
+
+	histogramViews, err := CreateHistogramViews(metricsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregationSelector, err := HistogramAggregationSelector(metricsConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+		return nil, err
+	}
+	if aggregationSelector != nil {
+		exporterOptions = append(exporterOptions, otelprom.WithAggregationSelector(aggregationSelector))
 	}
 
-	histogramViews := CreateHistogramViews(metricsConfig)
-	meterProvider := createMeterProvider(res, exporter, histogramViews)
+	exporter, err := createOtelPrometheusExporter(registry, exporterOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExporterInit, err)
+	}
+
+	readers := []sdkmetric.Reader{exporter}
+	if metricsConfig.OTLP.Endpoint != "" {
+		otlpReaders, err := newOTLPReaders(metricsConfig.OTLP, aggregationSelector)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, otlpReaders...)
+	}
+	if metricsConfig.ForwardOTelLogs {
+		forwardOTelLogs()
+	}
+	meterProvider := createMeterProvider(
+		res, readers, histogramViews, metricRenamer, metricsConfig.OmitCatchAllView, metricsConfig.DisabledInstruments,
+	)
 
 	if err := initializeRuntimeMetrics(meterProvider); err != nil {
-		return nil, fmt.Errorf("failed to initialize runtime metrics: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrRuntimeMetricsInit, err)
 	}
 
 	setGlobalMeterProvider(meterProvider)
 
-	httpHandler := createPrometheusHTTPHandler(registry)
+	wrapGatherer := func(gatherer prometheus.Gatherer) prometheus.Gatherer {
+		gatherer = newExcludingGatherer(
+			newLabelRenamingGatherer(gatherer, metricRenamer),
+			metricsConfig.ExcludeMetricNames,
+		)
+		gatherer = labels.wrap(gatherer)
+		for _, wrap := range metricsConfig.GathererWrappers {
+			gatherer = wrap(gatherer)
+		}
+		return gatherer
+	}
+	merged := newMergedGatherer(registry)
+	gatherer := wrapGatherer(merged)
+	tenants := newTenantRegistries(wrapGatherer, metricsConfig.ScrapeTimeout, metricsConfig.MaxConcurrentScrapes)
+	httpHandler := forceExpositionFormat(
+		tenants.routingHandler(
+			createPrometheusHTTPHandler(gatherer, metricsConfig.ScrapeTimeout, metricsConfig.MaxConcurrentScrapes),
+		),
+		accept,
+	)
 
 	// Extract service name from resource
-	serviceName := extractServiceName(res)
+	serviceName := extractServiceName(res, metricsConfig.ServiceNameFallback)
 
 	provider := &Provider{
 		registry:      registry,
 		exporter:      exporter,
 		meterProvider: meterProvider,
+		gatherer:      gatherer,
+		merged:        merged,
 		httpHandler:   httpHandler,
 		serviceName:   serviceName,
+		tenants:       tenants,
+		globalLabels:  labels,
 		cleanupFuncs: []func(){
 			func() { _ = exporter.Shutdown(context.Background()) },
 			func() { _ = meterProvider.Shutdown(context.Background()) },
 		},
 	}
 
+	if metricsConfig.RemoteWrite.URL != "" {
+		writer := newRemoteWriter(metricsConfig.RemoteWrite, gatherer)
+		provider.startPusher(newPeriodicPusher("remote_write", metricsConfig.RemoteWrite.Interval, writer.push))
+	}
+
+	if metricsConfig.Pushgateway.URL != "" {
+		provider.startPusher(
+			newPeriodicPusher(
+				"pushgateway",
+				metricsConfig.Pushgateway.Interval,
+				newPushgatewayPush(metricsConfig.Pushgateway, gatherer, serviceName),
+			),
+		)
+	}
+
 	return provider, nil
 }
 
 // HTTPHandler returns the HTTP handler for the Prometheus metrics endpoint.
+// Requests with a tenant query parameter (/metrics?tenant=foo) are served from
+// that tenant's registry; see RegistryFor.
 func (p *Provider) HTTPHandler() http.Handler {
 	return p.httpHandler
 }
 
+// Gatherer returns the gatherer behind the metrics endpoint, with exclusions and renames applied.
+func (p *Provider) Gatherer() prometheus.Gatherer {
+	return p.gatherer
+}
+
+// ForceFlush flushes all pending telemetry to the configured readers.
+// Tests use it to read exported metrics deterministically instead of waiting on periodic readers.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	return p.meterProvider.ForceFlush(ctx)
+}
+
+// startPusher starts pusher and stops it on Cleanup before the meter provider
+// shuts down, so the final push still sees the meter provider's data.
+func (p *Provider) startPusher(pusher *periodicPusher) {
+	pusher.start()
+	p.cleanupFuncs = append([]func(){pusher.shutdown}, p.cleanupFuncs...)
+}
+
 // Cleanup shuts down the exporter and meter provider.
 func (p *Provider) Cleanup() {
 	for _, cleanup := range p.cleanupFuncs {
@@ -82,9 +244,12 @@ func (p *Provider) Cleanup() {
 }
 
 func createPrometheusRegistry(metricsConfig config.MetricsConfig) *prometheus.Registry {
-	// Use NewPedanticRegistry to have more control over validation
-	// This avoids the "unset" validation scheme error
-	registry := prometheus.NewRegistry()
+	registry := metricsConfig.Registry
+	if registry == nil {
+		// Use NewPedanticRegistry to have more control over validation
+		// This avoids the "unset" validation scheme error
+		registry = prometheus.NewRegistry()
+	}
 
 	if metricsConfig.RegisterDefaultPrometheusRegistry {
 		prometheus.DefaultRegisterer = registry
@@ -93,26 +258,60 @@ func createPrometheusRegistry(metricsConfig config.MetricsConfig) *prometheus.Re
 	return registry
 }
 
-func createOtelPrometheusExporter(registry *prometheus.Registry) (*otelprom.Exporter, error) {
-	return otelprom.New(otelprom.WithRegisterer(registry))
+func createOtelPrometheusExporter(registry *prometheus.Registry, options ...otelprom.Option) (*otelprom.Exporter, error) {
+	return otelprom.New(append([]otelprom.Option{otelprom.WithRegisterer(registry)}, options...)...)
 }
 
-func createMeterProvider(res *resource.Resource, exporter *otelprom.Exporter,
-	views []sdkmetric.View) *sdkmetric.MeterProvider {
-	// Add default view for all metrics
-	defaultView := sdkmetric.NewView(
-		sdkmetric.Instrument{Name: "*"},
-		sdkmetric.Stream{Aggregation: sdkmetric.AggregationDefault{}},
-	)
-	views = append(views, defaultView)
+// exporterOptionsFor maps a NameValidationScheme to the exporter's translation strategy.
+func exporterOptionsFor(scheme string) ([]otelprom.Option, error) {
+	switch scheme {
+	case "":
+		return nil, nil
+	case "legacy":
+		return []otelprom.Option{otelprom.WithTranslationStrategy(otlptranslator.UnderscoreEscapingWithSuffixes)}, nil
+	case "utf8":
+		return []otelprom.Option{otelprom.WithTranslationStrategy(otlptranslator.NoUTF8EscapingWithSuffixes)}, nil
+	default:
+		return nil, fmt.Errorf("%w %q: must be \"legacy\" or \"utf8\"", ErrInvalidNameValidationScheme, scheme)
+	}
+}
+
+// createMeterProvider appends a catch-all view after views unless omitCatchAllView is set
+// and no renames are configured. The SDK keeps only the first stream per name when several
+// views match, so the catch-all never masks an earlier per-name view; it exists so the
+// renamer also sees instruments no other view matches. Instruments matching
+// disabledInstruments are dropped ahead of every view.
+func createMeterProvider(res *resource.Resource, readers []sdkmetric.Reader, views []sdkmetric.View,
+	metricRenamer *renamer, omitCatchAllView bool, disabledInstruments []string) *sdkmetric.MeterProvider {
+	if !omitCatchAllView || metricRenamer != nil {
+		// Leaving the aggregation unset uses the reader's default, which honours
+		// instrument advice such as ExplicitBucketBoundaries.
+		defaultView := sdkmetric.NewView(
+			sdkmetric.Instrument{Name: "*"},
+			sdkmetric.Stream{},
+		)
+		views = append(views, defaultView)
+	}
+	views = disableInstruments(views, disabledInstruments)
+	views = metricRenamer.wrapViews(views)
 
-	return sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(exporter),
+	options := []sdkmetric.Option{
 		sdkmetric.WithView(views...),
 		sdkmetric.WithResource(res),
-	)
+	}
+	for _, reader := range readers {
+		options = append(options, sdkmetric.WithReader(reader))
+	}
+
+	return sdkmetric.NewMeterProvider(options...)
 }
 
+// RuntimeGCPauseHistogramName is the instrument name of the runtime GC pause histogram,
+// exported as process_runtime_go_gc_pause_ns_nanoseconds. It is only produced with
+// OTEL_GO_X_DEPRECATED_RUNTIME_METRICS=true; key HistogramBoundariesByName by this name
+// to override its buckets.
+const RuntimeGCPauseHistogramName = "process.runtime.go.gc.pause_ns"
+
 func initializeRuntimeMetrics(meterProvider *sdkmetric.MeterProvider) error {
 
 	return runtime.Start(runtime.WithMeterProvider(meterProvider))
@@ -122,12 +321,16 @@ func setGlobalMeterProvider(meterProvider *sdkmetric.MeterProvider) {
 	otel.SetMeterProvider(meterProvider)
 }
 
-func createPrometheusHTTPHandler(registry *prometheus.Registry) http.Handler {
+// createPrometheusHTTPHandler serves gatherer, answering 503 to scrapes slower than timeout
+// or beyond maxInFlight concurrent ones. Zero disables either limit.
+func createPrometheusHTTPHandler(gatherer prometheus.Gatherer, timeout time.Duration, maxInFlight int) http.Handler {
 	logger := &promLogger{}
 
 	return promhttp.HandlerFor(
-		registry, promhttp.HandlerOpts{
-			ErrorLog: logger,
+		gatherer, promhttp.HandlerOpts{
+			ErrorLog:            logger,
+			Timeout:             timeout,
+			MaxRequestsInFlight: maxInFlight,
 		},
 	)
 }
@@ -154,6 +357,19 @@ func (p *Provider) GetMeter() metric.Meter {
 	return otel.GetMeterProvider().Meter(p.serviceName)
 }
 
+// MeterForScope returns a Meter for the instrumentation scope name, e.g. a library's import
+// path, and its version. On /metrics every series it records carries otel_scope_name and,
+// when version is not empty, otel_scope_version, so series from different library versions
+// can be told apart.
+func (p *Provider) MeterForScope(name string, version string) metric.Meter {
+	var options []metric.MeterOption
+	if version != "" {
+		options = append(options, metric.WithInstrumentationVersion(version))
+	}
+
+	return p.meterProvider.Meter(name, options...)
+}
+
 // GetDefaultMeter returns a Meter scoped to the OTEL_SERVICE_NAME environment variable.
 // This is a package-level convenience function that can be called after the provider is initialized.
 // It uses the global meter provider set by NewProvider.
@@ -163,13 +379,17 @@ func GetDefaultMeter() metric.Meter {
 }
 
 // extractServiceName extracts the service name from the OpenTelemetry resource.
-// Falls back to environment variable or "unknown-service" if not found.
-func extractServiceName(res *resource.Resource) string {
+// Falls back to the environment variable, then to fallback, then to the package-wide
+// service name fallback if not found.
+func extractServiceName(res *resource.Resource, fallback string) string {
 	if res != nil {
 		if value, ok := res.Set().Value(semconv.ServiceNameKey); ok {
 			return value.AsString()
 		}
 	}
+	if fallback != "" && os.Getenv("OTEL_SERVICE_NAME") == "" {
+		return fallback
+	}
 	return getServiceNameFromEnv()
 }
 
@@ -177,7 +397,34 @@ func extractServiceName(res *resource.Resource) string {
 func getServiceNameFromEnv() string {
 	serviceName := os.Getenv("OTEL_SERVICE_NAME")
 	if serviceName == "" {
-		serviceName = "unknown-service"
+		serviceName = ServiceNameFallback()
 	}
 	return serviceName
 }
+
+// SetServiceNameFallback sets the meter scope name used when neither the resource
+// nor OTEL_SERVICE_NAME provide a service name. An empty name restores the
+// "unknown-service" default. Use BinaryName() to attribute stray metrics to the executable.
+func SetServiceNameFallback(name string) {
+	serviceNameFallbackMutex.Lock()
+	defer serviceNameFallbackMutex.Unlock()
+
+	if name == "" {
+		name = DefaultServiceNameFallback
+	}
+	serviceNameFallback = name
+}
+
+// ServiceNameFallback returns the current service name fallback.
+func ServiceNameFallback() string {
+	serviceNameFallbackMutex.RLock()
+	defer serviceNameFallbackMutex.RUnlock()
+
+	return serviceNameFallback
+}
+
+// BinaryName returns the base name of the running executable,
+// suitable as a service name fallback.
+func BinaryName() string {
+	return filepath.Base(os.Args[0])
+}
@@ -2,14 +2,32 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/domesama/doakes/config"
+	"github.com/domesama/doakes/testutil"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestGetDefaultMeter(t *testing.T) {
@@ -86,6 +104,7 @@ func TestGetServiceNameFromEnv(t *testing.T) {
 	tests := []struct {
 		name            string
 		otelServiceName string
+		fallback        string
 		expected        string
 	}{
 		{
@@ -98,6 +117,18 @@ func TestGetServiceNameFromEnv(t *testing.T) {
 			otelServiceName: "",
 			expected:        "unknown-service",
 		},
+		{
+			name:            "OTEL_SERVICE_NAME takes precedence over fallback",
+			otelServiceName: "my-otel-service",
+			fallback:        "my-binary",
+			expected:        "my-otel-service",
+		},
+		{
+			name:            "Custom fallback",
+			otelServiceName: "",
+			fallback:        "my-binary",
+			expected:        "my-binary",
+		},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +142,9 @@ func TestGetServiceNameFromEnv(t *testing.T) {
 					os.Setenv("OTEL_SERVICE_NAME", tt.otelServiceName)
 				}
 
+				SetServiceNameFallback(tt.fallback)
+				defer SetServiceNameFallback("")
+
 				// Test
 				result := getServiceNameFromEnv()
 				if result != tt.expected {
@@ -123,3 +157,857 @@ func TestGetServiceNameFromEnv(t *testing.T) {
 		)
 	}
 }
+
+func TestProviderWithExternalRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	externalCounter := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "external_library_events_total",
+			Help: "Counter registered by another library",
+		},
+	)
+	registry.MustRegister(externalCounter)
+	externalCounter.Inc()
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.Registry = registry
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter, err := provider.GetMeter().Int64Counter("otel_events")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+
+	recorder := httptest.NewRecorder()
+	provider.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := recorder.Body.String()
+	if !strings.Contains(body, "external_library_events_total 1") {
+		t.Errorf("expected external metric in output, got:\n%s", body)
+	}
+	if !strings.Contains(body, "otel_events_total") {
+		t.Errorf("expected otel metric in output, got:\n%s", body)
+	}
+}
+
+func TestProviderInProcessScrape(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	helper := testutil.NewInProcessHelper(provider.HTTPHandler())
+	before := helper.ParseMetrics(t)
+
+	counter, err := provider.GetMeter().Int64Counter("in_process_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 3, metric.WithAttributes(attribute.String("route", "/")))
+
+	after := helper.ParseMetrics(t)
+	testutil.AssertCounterIncrease(t, before, after, "in_process_requests_total", map[string]string{"route": "/"}, 3)
+}
+
+func TestProviderExcludeMetricNames(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.ExcludeMetricNames = []string{"noisy_requests_total"}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	meter := provider.GetMeter()
+	noisy, err := meter.Int64Counter("noisy_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	kept, err := meter.Int64Counter("kept_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	noisy.Add(context.Background(), 1)
+	kept.Add(context.Background(), 1)
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	scraped.AssertNoMetric(t, "noisy_requests_total", nil)
+	scraped.AssertCounter(t, "kept_requests_total", nil, 1)
+}
+
+func TestProviderGathererWrappers(t *testing.T) {
+	var wrapped []string
+	wrapper := func(name string) func(prometheus.Gatherer) prometheus.Gatherer {
+		return func(next prometheus.Gatherer) prometheus.Gatherer {
+			return prometheus.GathererFunc(
+				func() ([]*prometheusClient.MetricFamily, error) {
+					wrapped = append(wrapped, name)
+					families, err := next.Gather()
+					return slices.DeleteFunc(
+						families, func(family *prometheusClient.MetricFamily) bool {
+							return family.GetName() == "dropped_requests_total"
+						},
+					), err
+				},
+			)
+		}
+	}
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.ExcludeMetricNames = []string{"excluded_requests_total"}
+	metricsConfig.GathererWrappers = []func(prometheus.Gatherer) prometheus.Gatherer{wrapper("inner"), wrapper("outer")}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	meter := provider.GetMeter()
+	for _, name := range []string{"dropped_requests", "excluded_requests", "kept_requests"} {
+		counter, err := meter.Int64Counter(name)
+		if err != nil {
+			t.Fatalf("failed to create counter: %v", err)
+		}
+		counter.Add(context.Background(), 1)
+	}
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	scraped.AssertNoMetric(t, "dropped_requests_total", nil)
+	scraped.AssertNoMetric(t, "excluded_requests_total", nil)
+	scraped.AssertCounter(t, "kept_requests_total", nil, 1)
+
+	if !slices.Equal(wrapped, []string{"outer", "inner"}) {
+		t.Errorf("expected the last wrapper to run outermost, got %v", wrapped)
+	}
+}
+
+func TestProviderGlobalLabels(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.GlobalLabels = map[string]string{"region": "eu-west-1"}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter, err := provider.GetMeter().Int64Counter("sharded_jobs")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("region", "us-east-1")))
+	counter.Add(context.Background(), 2)
+
+	helper := testutil.NewInProcessHelper(provider.HTTPHandler())
+	scraped := helper.ParseMetrics(t)
+	scraped.AssertCounter(t, "sharded_jobs_total", map[string]string{"region": "us-east-1"}, 1)
+	scraped.AssertCounter(t, "sharded_jobs_total", map[string]string{"region": "eu-west-1"}, 2)
+	scraped.AssertNoMetric(t, "sharded_jobs_total", map[string]string{"shard": "3"})
+
+	if err := provider.SetGlobalLabel("shard", "3"); err != nil {
+		t.Fatalf("failed to set global label: %v", err)
+	}
+	helper.ParseMetrics(t).AssertCounter(t, "sharded_jobs_total", map[string]string{"region": "eu-west-1", "shard": "3"}, 2)
+
+	provider.DeleteGlobalLabel("shard")
+	helper.ParseMetrics(t).AssertNoMetric(t, "sharded_jobs_total", map[string]string{"shard": "3"})
+
+	if err := provider.SetGlobalLabel("__name__", "x"); !errors.Is(err, ErrInvalidGlobalLabel) {
+		t.Fatalf("expected ErrInvalidGlobalLabel, got %v", err)
+	}
+}
+
+func TestProviderRenameRules(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.RenameRules = []config.RenameRule{
+		{Match: `^legacy_(.*)$`, Replace: "app_$1"},
+		{Match: `^route$`, Replace: "http_route"},
+	}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter, err := provider.GetMeter().Int64Counter("legacy_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 2, metric.WithAttributes(attribute.String("route", "/")))
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	scraped.AssertNoMetric(t, "legacy_requests_total", nil)
+	scraped.AssertCounter(t, "app_requests_total", map[string]string{"http_route": "/"}, 2)
+}
+
+func TestProviderRenameRulesSkipUnsafeLabels(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.RenameRules = []config.RenameRule{
+		{Match: `^route$`, Replace: "path"},
+		{Match: `^method$`, Replace: "path"},
+		{Match: `^bucket$`, Replace: "le"},
+		{Match: `^drop$`, Replace: ""},
+		{Match: `^otel_scope_name$`, Replace: "scope"},
+		{Match: `^service_name$`, Replace: "service"},
+	}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter, err := provider.GetMeter().Int64Counter("requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("route", "/"),
+		attribute.String("method", "GET"),
+		attribute.String("bucket", "b1"),
+		attribute.String("drop", "d"),
+	))
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	requests := scraped.GetSingle(t, "requests_total", nil)
+
+	var names []string
+	for _, pair := range requests.GetLabel() {
+		names = append(names, pair.GetName())
+	}
+	// Labels are renamed in name order, so method takes path and route keeps its name
+	for _, want := range []string{"path", "route", "bucket", "drop", "otel_scope_name"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected label %q to be kept, got %v", want, names)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("expected labels sorted by name, got %v", names)
+	}
+
+	if len(scraped.Get("target_info", map[string]string{"service_name": "unknown_service:metrics.test"})) != 1 {
+		t.Errorf("expected target_info labels to be left alone")
+	}
+}
+
+func TestProviderInvalidRenameRule(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.RenameRules = []config.RenameRule{{Match: "(", Replace: "x"}}
+
+	_, err := NewProvider(resource.Default(), metricsConfig)
+	if !errors.Is(err, ErrInvalidRenameRule) {
+		t.Fatalf("expected ErrInvalidRenameRule, got %v", err)
+	}
+}
+
+func TestProviderFlushAndGather(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	histogram, err := provider.GetMeter().Int64Histogram("flushed_duration_ms")
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+	histogram.Record(context.Background(), 42)
+
+	gathered := testutil.FlushAndGather(t, provider)
+	gathered.AssertHistogramCount(t, "flushed_duration_ms", nil, 1)
+	if _, ok := gathered.Families()["flushed_duration_ms"]; !ok {
+		t.Errorf("expected flushed_duration_ms family")
+	}
+}
+
+func TestProviderRegistryFor(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	fooRequests := prometheus.NewCounter(prometheus.CounterOpts{Name: "foo_requests_total"})
+	provider.RegistryFor("foo").MustRegister(fooRequests)
+	fooRequests.Add(2)
+
+	if provider.RegistryFor("foo") != provider.RegistryFor("foo") {
+		t.Fatal("expected RegistryFor to return the same registry for a tenant")
+	}
+
+	scrape := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		provider.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	tenantScrape := scrape("/metrics?tenant=foo")
+	if tenantScrape.Code != http.StatusOK {
+		t.Fatalf("expected 200 for tenant scrape, got %d", tenantScrape.Code)
+	}
+	if !strings.Contains(tenantScrape.Body.String(), "foo_requests_total 2") {
+		t.Errorf("expected tenant metric in output, got:\n%s", tenantScrape.Body.String())
+	}
+	if strings.Contains(tenantScrape.Body.String(), "go_goroutine") {
+		t.Errorf("expected tenant scrape to exclude default metrics, got:\n%s", tenantScrape.Body.String())
+	}
+
+	if body := scrape("/metrics").Body.String(); strings.Contains(body, "foo_requests_total") {
+		t.Errorf("expected default scrape to exclude tenant metrics, got:\n%s", body)
+	}
+
+	if code := scrape("/metrics?tenant=bar").Code; code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown tenant, got %d", code)
+	}
+}
+
+func TestProviderBuildInfo(t *testing.T) {
+	res := resource.NewSchemaless(semconv.ServiceVersionKey.String("1.2.3"))
+
+	provider, err := NewProvider(res, config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	scraped.AssertGauge(t, "service_build_info", map[string]string{"version": "1.2.3", "goversion": runtime.Version()}, 1)
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.BuildInfoMetricName = ""
+
+	disabled, err := NewProvider(res, metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer disabled.Cleanup()
+
+	testutil.NewInProcessHelper(disabled.HTTPHandler()).ParseMetrics(t).AssertNoMetric(t, "service_build_info", nil)
+}
+
+func TestProviderNameValidationScheme(t *testing.T) {
+	tests := []struct {
+		scheme   string
+		wantName string
+	}{
+		{scheme: "legacy", wantName: "http_requests_total"},
+		{scheme: "utf8", wantName: "http.requests_total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.scheme, func(t *testing.T) {
+				metricsConfig := config.DefaultMetricsConfig()
+				metricsConfig.NameValidationScheme = tt.scheme
+
+				provider, err := NewProvider(resource.Default(), metricsConfig)
+				if err != nil {
+					t.Fatalf("failed to create provider: %v", err)
+				}
+				defer provider.Cleanup()
+
+				counter, err := provider.GetMeter().Int64Counter("http.requests")
+				if err != nil {
+					t.Fatalf("failed to create counter: %v", err)
+				}
+				counter.Add(context.Background(), 1)
+
+				families, err := provider.Gatherer().Gather()
+				if err != nil {
+					t.Fatalf("failed to gather: %v", err)
+				}
+				for _, family := range families {
+					if family.GetName() == tt.wantName {
+						return
+					}
+				}
+				t.Fatalf("metric %q not found", tt.wantName)
+			},
+		)
+	}
+}
+
+func TestProviderInvalidNameValidationScheme(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.NameValidationScheme = "ascii"
+
+	_, err := NewProvider(resource.Default(), metricsConfig)
+	if !errors.Is(err, ErrInvalidNameValidationScheme) {
+		t.Fatalf("expected ErrInvalidNameValidationScheme, got %v", err)
+	}
+}
+
+func TestProviderDescribeMetrics(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter, err := provider.GetMeter().Int64Counter("catalog_requests", metric.WithDescription("Requests served"))
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("route", "/")))
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("method", "GET")))
+
+	for _, descriptor := range provider.DescribeMetrics() {
+		if descriptor.Name != "catalog_requests_total" {
+			continue
+		}
+
+		if descriptor.Type != "counter" || descriptor.Help != "Requests served" {
+			t.Fatalf("unexpected descriptor: %+v", descriptor)
+		}
+		if !slices.IsSorted(descriptor.LabelKeys) ||
+			!slices.Contains(descriptor.LabelKeys, "method") || !slices.Contains(descriptor.LabelKeys, "route") {
+			t.Fatalf("unexpected label keys: %v", descriptor.LabelKeys)
+		}
+		return
+	}
+	t.Fatalf("catalog_requests_total not described")
+}
+
+// slowCollector blocks collection until released, simulating a pathological collector.
+type slowCollector struct {
+	// started, when set, is closed once the first collection begins
+	started   chan struct{}
+	startOnce sync.Once
+	release   chan struct{}
+	desc      *prometheus.Desc
+}
+
+func (c *slowCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.desc
+}
+
+func (c *slowCollector) Collect(metrics chan<- prometheus.Metric) {
+	if c.started != nil {
+		c.startOnce.Do(func() { close(c.started) })
+	}
+	<-c.release
+	metrics <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+}
+
+func TestProviderScrapeTimeout(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.ScrapeTimeout = 50 * time.Millisecond
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	collector := &slowCollector{
+		release: make(chan struct{}),
+		desc:    prometheus.NewDesc("slow_metric", "Blocks collection", nil, nil),
+	}
+	defer close(collector.release)
+	provider.RegistryFor("slow").MustRegister(collector)
+
+	recorder := httptest.NewRecorder()
+	provider.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics?tenant=slow", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 on scrape timeout, got %d", recorder.Code)
+	}
+}
+
+func TestProviderMaxConcurrentScrapes(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.ScrapeTimeout = 0
+	metricsConfig.MaxConcurrentScrapes = 1
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	collector := &slowCollector{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		desc:    prometheus.NewDesc("slow_metric", "Blocks collection", nil, nil),
+	}
+	provider.RegistryFor("slow").MustRegister(collector)
+
+	firstDone := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		provider.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics?tenant=slow", nil))
+		firstDone <- recorder.Code
+	}()
+	<-collector.started
+
+	recorder := httptest.NewRecorder()
+	provider.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics?tenant=slow", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 beyond the concurrency limit, got %d", recorder.Code)
+	}
+
+	close(collector.release)
+	if code := <-firstDone; code != http.StatusOK {
+		t.Errorf("expected the in-flight scrape to succeed, got %d", code)
+	}
+}
+
+func TestMustInstrumentsCarryHelpAndUnit(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter := MustInt64Counter(provider.GetMeter(), "upload_size", "Bytes uploaded by clients", "By")
+	counter.Add(context.Background(), 512)
+
+	families := testutil.FlushAndGather(t, provider).Families()
+	family, ok := families["upload_size_bytes_total"]
+	if !ok {
+		t.Fatalf("upload_size_bytes_total not exported, got %v", slices.Collect(maps.Keys(families)))
+	}
+	if family.GetHelp() != "Bytes uploaded by clients" {
+		t.Fatalf("unexpected HELP %q", family.GetHelp())
+	}
+}
+
+func TestMustInstrumentPanicsOnInvalidName(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for an invalid instrument name")
+		}
+	}()
+	MustFloat64Histogram(provider.GetMeter(), "1-invalid name", "Invalid", "s")
+}
+
+// failingCollector always reports a collection error.
+type failingCollector struct {
+	desc *prometheus.Desc
+}
+
+func (c *failingCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.desc
+}
+
+func (c *failingCollector) Collect(metrics chan<- prometheus.Metric) {
+	metrics <- prometheus.NewInvalidMetric(c.desc, errors.New("backend unavailable"))
+}
+
+func TestScrapableCheck(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.Registry = registry
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	check := ScrapableCheck(provider)
+	if err := check(); err != nil {
+		t.Fatalf("expected a gatherable registry, got %v", err)
+	}
+
+	registry.MustRegister(&failingCollector{desc: prometheus.NewDesc("failing_metric", "Always fails", nil, nil)})
+	if err := check(); err == nil {
+		t.Fatalf("expected an error once a collector fails")
+	}
+}
+
+func TestReadCPUQuota(t *testing.T) {
+	tests := []struct {
+		content string
+		want    float64
+		wantOK  bool
+	}{
+		{content: "150000 100000\n", want: 1.5, wantOK: true},
+		{content: "max 100000\n", wantOK: false},
+		{content: "garbage\n", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "cpu.max")
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatalf("failed to write cpu.max: %v", err)
+		}
+
+		got, ok := readCPUQuota(path)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("readCPUQuota(%q) = %v, %v; want %v, %v", tt.content, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok := readCPUQuota(filepath.Join(t.TempDir(), "missing")); ok {
+		t.Error("expected no quota for a missing file")
+	}
+}
+
+func TestProviderHistogramExemplar(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	histogram, err := provider.GetMeter().Float64Histogram("traced_request_duration")
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+
+	spanContext := trace.NewSpanContext(
+		trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+			SpanID:     trace.SpanID{0x04, 0x05},
+			TraceFlags: trace.FlagsSampled,
+		},
+	)
+	histogram.Record(trace.ContextWithSpanContext(context.Background(), spanContext), 42)
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	scraped.AssertHistogramExemplar(
+		t, "traced_request_duration", nil, map[string]string{"trace_id": spanContext.TraceID().String()},
+	)
+}
+
+func TestProviderExpositionFormat(t *testing.T) {
+	protobufAccept := string(expfmt.NewFormat(expfmt.TypeProtoDelim))
+
+	contentType := func(t *testing.T, format string, accept string) string {
+		metricsConfig := config.DefaultMetricsConfig()
+		metricsConfig.ExpositionFormat = format
+
+		provider, err := NewProvider(resource.Default(), metricsConfig)
+		if err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+		defer provider.Cleanup()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		provider.HTTPHandler().ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", recorder.Code)
+		}
+		return recorder.Header().Get("Content-Type")
+	}
+
+	if got := contentType(t, "", protobufAccept); expfmt.ResponseFormat(http.Header{"Content-Type": {got}}).FormatType() != expfmt.TypeProtoDelim {
+		t.Errorf("expected negotiated protobuf, got %q", got)
+	}
+	if got := contentType(t, "", "text/plain"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("expected negotiated text, got %q", got)
+	}
+	if got := contentType(t, "text", protobufAccept); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("expected forced text, got %q", got)
+	}
+	if got := contentType(t, "protobuf", "text/plain"); expfmt.ResponseFormat(http.Header{"Content-Type": {got}}).FormatType() != expfmt.TypeProtoDelim {
+		t.Errorf("expected forced protobuf, got %q", got)
+	}
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.ExpositionFormat = "json"
+	if _, err := NewProvider(resource.Default(), metricsConfig); !errors.Is(err, ErrInvalidExpositionFormat) {
+		t.Fatalf("expected ErrInvalidExpositionFormat, got %v", err)
+	}
+}
+
+// recordingHandler keeps the records logged through slog for assertions.
+type recordingHandler struct {
+	mutex   sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+// hasConflictWarning reports whether OTel's duplicate instrument warning was recorded.
+func (h *recordingHandler) hasConflictWarning() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, record := range h.records {
+		if record.Message == "duplicate metric stream definitions" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProviderForwardsOTelConflictWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		forward bool
+	}{
+		{name: "forwarded", forward: true},
+		{name: "kept by default", forward: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				slogHandler := &recordingHandler{}
+				previous := slog.Default()
+				slog.SetDefault(slog.New(slogHandler))
+				t.Cleanup(func() { slog.SetDefault(previous) })
+
+				// A logger the application installed, which only ForwardOTelLogs replaces
+				installedHandler := &recordingHandler{}
+				otel.SetLogger(logr.FromSlogHandler(installedHandler))
+
+				metricsConfig := config.DefaultMetricsConfig()
+				metricsConfig.ForwardOTelLogs = tt.forward
+				provider, err := NewProvider(resource.Default(), metricsConfig)
+				if err != nil {
+					t.Fatalf("failed to create provider: %v", err)
+				}
+				defer provider.Cleanup()
+
+				for _, unit := range []string{"ms", "s"} {
+					histogram, err := provider.GetMeter().Float64Histogram("test_histogram", metric.WithUnit(unit))
+					if err != nil {
+						t.Fatalf("failed to create histogram: %v", err)
+					}
+					histogram.Record(context.Background(), 1)
+				}
+
+				forwarded, installed := slogHandler.hasConflictWarning(), installedHandler.hasConflictWarning()
+				if forwarded != tt.forward {
+					t.Errorf("expected warning forwarded to slog: %v, got %v", tt.forward, forwarded)
+				}
+				if installed == tt.forward {
+					t.Errorf("expected warning on the installed logger: %v, got %v", !tt.forward, installed)
+				}
+			},
+		)
+	}
+}
+
+func TestProviderMeterForScope(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	for _, version := range []string{"1.2.0", "1.3.0"} {
+		counter, err := provider.MeterForScope("github.com/example/client", version).Int64Counter("client_calls")
+		if err != nil {
+			t.Fatalf("failed to create counter: %v", err)
+		}
+		counter.Add(context.Background(), 1)
+	}
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	for _, version := range []string{"1.2.0", "1.3.0"} {
+		series := scraped.Get("client_calls_total", map[string]string{
+			"otel_scope_name":    "github.com/example/client",
+			"otel_scope_version": version,
+		})
+		if len(series) != 1 {
+			t.Errorf("expected one client_calls_total series for version %s, got %d", version, len(series))
+		}
+	}
+}
+
+func TestProviderAddGatherer(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.GlobalLabels = map[string]string{"region": "eu-west-1"}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	counter, err := provider.GetMeter().Int64Counter("own_jobs")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+
+	external := prometheus.NewRegistry()
+	externalJobs := prometheus.NewCounter(prometheus.CounterOpts{Name: "external_jobs_total", Help: "Jobs run."})
+	external.MustRegister(externalJobs)
+	externalJobs.Add(3)
+	provider.AddGatherer(external)
+
+	scraped := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	if scraped.GetSingle(t, "own_jobs_total", nil) == nil {
+		t.Error("expected the provider's own metrics")
+	}
+	series := scraped.GetSingle(t, "external_jobs_total", map[string]string{"region": "eu-west-1"})
+	if series == nil {
+		t.Fatal("expected the added gatherer's metrics with the global labels")
+	}
+	if value := series.GetCounter().GetValue(); value != 3 {
+		t.Errorf("expected external_jobs_total 3, got %v", value)
+	}
+}
+
+func TestProviderDisabledInstruments(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.DisabledInstruments = []string{"chatty_requests", "noisy.*", "chatty_wait_ns"}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	meter := provider.GetMeter()
+	for _, name := range []string{"chatty_requests", "noisy.cache_hits", "kept_requests"} {
+		counter, err := meter.Int64Counter(name)
+		if err != nil {
+			t.Fatalf("failed to create counter %s: %v", name, err)
+		}
+		counter.Add(context.Background(), 1)
+	}
+	// Also matched by the default "*_ns" histogram view
+	histogram, err := meter.Float64Histogram("chatty_wait_ns")
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+	histogram.Record(context.Background(), 1)
+
+	scraped := testutil.FlushAndGather(t, provider)
+	for _, name := range []string{"chatty_requests_total", "noisy_cache_hits_total", "chatty_wait_ns"} {
+		if len(scraped.Get(name, nil)) != 0 {
+			t.Errorf("expected disabled %s to be dropped", name)
+		}
+	}
+	if scraped.GetSingle(t, "kept_requests_total", nil) == nil {
+		t.Error("expected kept_requests_total to be exported")
+	}
+
+	testutil.AssertMetricDropped(t, provider.GetMeter(), testutil.NewInProcessHelper(provider.HTTPHandler()), "noisy.retries")
+}
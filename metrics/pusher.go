@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// periodicPusher calls push every interval and once more on shutdown, so the last
+// interval isn't lost. A zero interval only pushes on shutdown. Failed pushes are
+// logged and retried on the next tick.
+type periodicPusher struct {
+	name     string
+	interval time.Duration
+	push     func(ctx context.Context) error
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newPeriodicPusher(name string, interval time.Duration, push func(ctx context.Context) error) *periodicPusher {
+	return &periodicPusher{
+		name:     name,
+		interval: interval,
+		push:     push,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *periodicPusher) start() {
+	go p.run()
+}
+
+func (p *periodicPusher) shutdown() {
+	p.once.Do(
+		func() {
+			close(p.stop)
+			<-p.done
+		},
+	)
+}
+
+func (p *periodicPusher) run() {
+	defer close(p.done)
+
+	var ticks <-chan time.Time
+	if p.interval > 0 {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-ticks:
+			p.pushAndLog()
+		case <-p.stop:
+			p.pushAndLog()
+			return
+		}
+	}
+}
+
+func (p *periodicPusher) pushAndLog() {
+	if err := p.push(context.Background()); err != nil {
+		slog.Error("Metrics push failed", "pusher", p.name, "error", err)
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/domesama/doakes/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// newPushgatewayPush returns a push function that replaces this job's metrics on the
+// Pushgateway with the current contents of gatherer.
+func newPushgatewayPush(
+	pushgatewayConfig config.PushgatewayConfig,
+	gatherer prometheus.Gatherer,
+	serviceName string,
+) func(ctx context.Context) error {
+	job := pushgatewayConfig.Job
+	if job == "" {
+		job = serviceName
+	}
+
+	pusher := push.New(pushgatewayConfig.URL, job).
+		Gatherer(gatherer).
+		Client(&http.Client{Timeout: pushgatewayConfig.Timeout})
+
+	for name, value := range pushgatewayConfig.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+
+	return pusher.PushContext
+}
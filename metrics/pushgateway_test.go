@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/domesama/doakes/config"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+func TestProviderPushgatewayOnCleanup(t *testing.T) {
+	type pushRequest struct {
+		method string
+		path   string
+		body   string
+	}
+	pushes := make(chan pushRequest, 16)
+
+	gateway := httptest.NewServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				pushes <- pushRequest{method: req.Method, path: req.URL.Path, body: string(body)}
+				writer.WriteHeader(http.StatusOK)
+			},
+		),
+	)
+	defer gateway.Close()
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.Pushgateway = config.PushgatewayConfig{
+		URL:      gateway.URL,
+		Grouping: map[string]string{"shard": "1"},
+		Timeout:  time.Second,
+	}
+
+	res := resource.NewSchemaless(semconv.ServiceNameKey.String("nightly-import"))
+	provider, err := NewProvider(res, metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	counter, err := provider.GetMeter().Int64Counter("imported_rows")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 10)
+
+	select {
+	case <-pushes:
+		t.Fatal("expected no push before cleanup when no interval is set")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	provider.Cleanup()
+
+	select {
+	case push := <-pushes:
+		if push.method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", push.method)
+		}
+		if push.path != "/metrics/job/nightly-import/shard/1" {
+			t.Errorf("unexpected push path %q", push.path)
+		}
+		if !strings.Contains(push.body, "imported_rows_total") {
+			t.Errorf("expected imported_rows_total in pushed body")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a push on cleanup")
+	}
+}
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/domesama/doakes/config"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriter pushes gathered metric families to a Prometheus remote-write (v1) endpoint.
+type remoteWriter struct {
+	config     config.RemoteWriteConfig
+	gatherer   prometheus.Gatherer
+	httpClient *http.Client
+}
+
+func newRemoteWriter(remoteWriteConfig config.RemoteWriteConfig, gatherer prometheus.Gatherer) *remoteWriter {
+	return &remoteWriter{
+		config:     remoteWriteConfig,
+		gatherer:   gatherer,
+		httpClient: &http.Client{Timeout: remoteWriteConfig.Timeout},
+	}
+}
+
+func (w *remoteWriter) push(ctx context.Context) error {
+	families, err := w.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(families, time.Now().UnixMilli()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if w.config.Username != "" {
+		req.SetBasicAuth(w.config.Username, w.config.Password)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Field numbers from the remote-write v1 protobuf (prometheus/prompb).
+const (
+	writeRequestTimeseriesField = 1
+	timeSeriesLabelsField       = 1
+	timeSeriesSamplesField      = 2
+	labelNameField              = 1
+	labelValueField             = 2
+	sampleValueField            = 1
+	sampleTimestampField        = 2
+)
+
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+// encodeWriteRequest encodes families as a prompb.WriteRequest, flattening histograms
+// and summaries into their _bucket/quantile, _sum and _count series as a scrape would.
+func encodeWriteRequest(families []*prometheusClient.MetricFamily, timestampMs int64) []byte {
+	var request []byte
+
+	appendSeries := func(name string, labels []remoteWriteLabel, value float64) {
+		series := encodeTimeSeries(name, labels, value, timestampMs)
+		request = protowire.AppendTag(request, writeRequestTimeseriesField, protowire.BytesType)
+		request = protowire.AppendBytes(request, series)
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+
+		for _, metric := range family.GetMetric() {
+			labels := make([]remoteWriteLabel, 0, len(metric.GetLabel())+1)
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, remoteWriteLabel{name: label.GetName(), value: label.GetValue()})
+			}
+
+			switch family.GetType() {
+			case prometheusClient.MetricType_COUNTER:
+				appendSeries(name, labels, metric.GetCounter().GetValue())
+
+			case prometheusClient.MetricType_GAUGE:
+				appendSeries(name, labels, metric.GetGauge().GetValue())
+
+			case prometheusClient.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				for _, bucket := range histogram.GetBucket() {
+					le := strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64)
+					appendSeries(
+						name+"_bucket",
+						append(labels, remoteWriteLabel{name: "le", value: le}),
+						float64(bucket.GetCumulativeCount()),
+					)
+				}
+				appendSeries(
+					name+"_bucket",
+					append(labels, remoteWriteLabel{name: "le", value: "+Inf"}),
+					float64(histogram.GetSampleCount()),
+				)
+				appendSeries(name+"_sum", labels, histogram.GetSampleSum())
+				appendSeries(name+"_count", labels, float64(histogram.GetSampleCount()))
+
+			case prometheusClient.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					q := strconv.FormatFloat(quantile.GetQuantile(), 'g', -1, 64)
+					appendSeries(
+						name,
+						append(labels, remoteWriteLabel{name: "quantile", value: q}),
+						quantile.GetValue(),
+					)
+				}
+				appendSeries(name+"_sum", labels, summary.GetSampleSum())
+				appendSeries(name+"_count", labels, float64(summary.GetSampleCount()))
+
+			default:
+				appendSeries(name, labels, metric.GetUntyped().GetValue())
+			}
+		}
+	}
+
+	return request
+}
+
+func encodeTimeSeries(name string, labels []remoteWriteLabel, value float64, timestampMs int64) []byte {
+	// Remote write requires labels sorted by name, including __name__
+	sorted := make([]remoteWriteLabel, 0, len(labels)+1)
+	sorted = append(sorted, remoteWriteLabel{name: "__name__", value: name})
+	sorted = append(sorted, labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	var series []byte
+	for _, label := range sorted {
+		var encoded []byte
+		encoded = protowire.AppendTag(encoded, labelNameField, protowire.BytesType)
+		encoded = protowire.AppendString(encoded, label.name)
+		encoded = protowire.AppendTag(encoded, labelValueField, protowire.BytesType)
+		encoded = protowire.AppendString(encoded, label.value)
+
+		series = protowire.AppendTag(series, timeSeriesLabelsField, protowire.BytesType)
+		series = protowire.AppendBytes(series, encoded)
+	}
+
+	var sample []byte
+	sample = protowire.AppendTag(sample, sampleValueField, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, sampleTimestampField, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestampMs))
+
+	series = protowire.AppendTag(series, timeSeriesSamplesField, protowire.BytesType)
+	series = protowire.AppendBytes(series, sample)
+
+	return series
+}
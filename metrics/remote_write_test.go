@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/domesama/doakes/config"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestProviderRemoteWrite(t *testing.T) {
+	type pushRequest struct {
+		header   http.Header
+		username string
+		password string
+		body     []byte
+	}
+	pushes := make(chan pushRequest, 16)
+
+	remote := httptest.NewServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, req *http.Request) {
+				compressed, _ := io.ReadAll(req.Body)
+				body, err := snappy.Decode(nil, compressed)
+				if err != nil {
+					writer.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				username, password, _ := req.BasicAuth()
+				pushes <- pushRequest{header: req.Header, username: username, password: password, body: body}
+				writer.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	defer remote.Close()
+
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.RemoteWrite = config.RemoteWriteConfig{
+		URL:      remote.URL,
+		Interval: time.Hour,
+		Timeout:  time.Second,
+		Username: "user",
+		Password: "secret",
+		Headers:  map[string]string{"X-Scope-OrgID": "team-a"},
+	}
+
+	provider, err := NewProvider(resource.Default(), metricsConfig)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	counter, err := provider.GetMeter().Int64Counter("remote_written_requests")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+
+	// Cleanup performs a final push
+	provider.Cleanup()
+
+	select {
+	case push := <-pushes:
+		if push.header.Get("Content-Encoding") != "snappy" {
+			t.Errorf("expected snappy encoding, got %q", push.header.Get("Content-Encoding"))
+		}
+		if push.header.Get("X-Scope-OrgID") != "team-a" {
+			t.Errorf("expected custom header, got %q", push.header.Get("X-Scope-OrgID"))
+		}
+		if push.username != "user" || push.password != "secret" {
+			t.Errorf("expected basic auth, got %q/%q", push.username, push.password)
+		}
+		if !strings.Contains(string(push.body), "remote_written_requests_total") {
+			t.Errorf("expected pushed series for remote_written_requests_total")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a remote write push")
+	}
+}
+
+func TestProviderRemoteWriteInvalidInterval(t *testing.T) {
+	metricsConfig := config.DefaultMetricsConfig()
+	metricsConfig.RemoteWrite = config.RemoteWriteConfig{URL: "http://localhost:1"}
+
+	if _, err := NewProvider(resource.Default(), metricsConfig); !errors.Is(err, ErrInvalidRemoteWrite) {
+		t.Fatalf("expected ErrInvalidRemoteWrite, got %v", err)
+	}
+}
+
+func TestEncodeWriteRequestHistogram(t *testing.T) {
+	histogram := prometheus.NewHistogram(
+		prometheus.HistogramOpts{Name: "latency_ms", Buckets: []float64{10, 100}},
+	)
+	histogram.Observe(42)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(histogram)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather: %v", err)
+	}
+
+	var names []string
+	request := encodeWriteRequest(families, 1000)
+	for len(request) > 0 {
+		_, _, n := protowire.ConsumeTag(request)
+		series, m := protowire.ConsumeBytes(request[n:])
+		request = request[n+m:]
+
+		// The first label is __name__, since labels are sorted
+		_, _, n = protowire.ConsumeTag(series)
+		label, _ := protowire.ConsumeBytes(series[n:])
+		_, _, n = protowire.ConsumeTag(label)
+		labelName, m := protowire.ConsumeString(label[n:])
+		_, _, k := protowire.ConsumeTag(label[n+m:])
+		labelValue, _ := protowire.ConsumeString(label[n+m+k:])
+
+		if labelName != "__name__" {
+			t.Fatalf("expected __name__ as first label, got %q", labelName)
+		}
+		names = append(names, labelValue)
+	}
+
+	expected := []string{
+		"latency_ms_bucket", "latency_ms_bucket", "latency_ms_bucket",
+		"latency_ms_sum", "latency_ms_count",
+	}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected series %v, got %v", expected, names)
+	}
+}
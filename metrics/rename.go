@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/domesama/doakes/config"
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// renamer applies config.RenameRules in order. A nil renamer renames nothing.
+type renamer struct {
+	rules []compiledRenameRule
+}
+
+type compiledRenameRule struct {
+	match   *regexp.Regexp
+	replace string
+}
+
+func newRenamer(rules []config.RenameRule) (*renamer, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	compiled := make([]compiledRenameRule, 0, len(rules))
+	for _, rule := range rules {
+		match, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidRenameRule, rule.Match, err)
+		}
+		compiled = append(compiled, compiledRenameRule{match: match, replace: rule.Replace})
+	}
+
+	return &renamer{rules: compiled}, nil
+}
+
+func (r *renamer) rename(name string) string {
+	if r == nil {
+		return name
+	}
+
+	for _, rule := range r.rules {
+		name = rule.match.ReplaceAllString(name, rule.replace)
+	}
+	return name
+}
+
+// wrapViews renames the stream produced by each view. Instrument names are matched
+// against the original name, so histogram patterns keep working after a rename.
+func (r *renamer) wrapViews(views []sdkmetric.View) []sdkmetric.View {
+	if r == nil {
+		return views
+	}
+
+	wrapped := make([]sdkmetric.View, 0, len(views))
+	for _, view := range views {
+		wrapped = append(wrapped, r.wrapView(view))
+	}
+	return wrapped
+}
+
+func (r *renamer) wrapView(view sdkmetric.View) sdkmetric.View {
+	return func(instrument sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		stream, ok := view(instrument)
+		if !ok {
+			return stream, false
+		}
+
+		name := stream.Name
+		if name == "" {
+			name = instrument.Name
+		}
+		stream.Name = r.rename(name)
+
+		return stream, true
+	}
+}
+
+// targetInfoFamily is the family the Prometheus exporter exposes resource attributes on.
+// Its labels are left alone, so joins against it in PromQL keep working.
+const targetInfoFamily = "target_info"
+
+// labelRenamingGatherer renames label keys at scrape time. OTel views can drop
+// attributes but not rename them, so attribute keys are rewritten here instead,
+// matched against their exposed (Prometheus-sanitized) label names.
+//
+// Reserved labels (le, quantile, otel_scope_* and __*) are never renamed, and a rename
+// to an invalid, reserved or already present name is skipped with a warning, since it
+// would make the exposition invalid.
+type labelRenamingGatherer struct {
+	gatherer prometheus.Gatherer
+	renamer  *renamer
+	// warned holds the "from -> to" renames already warned about, to log each once
+	warned sync.Map
+}
+
+// newLabelRenamingGatherer returns gatherer unchanged when there are no rename rules.
+func newLabelRenamingGatherer(gatherer prometheus.Gatherer, labelRenamer *renamer) prometheus.Gatherer {
+	if labelRenamer == nil {
+		return gatherer
+	}
+
+	return &labelRenamingGatherer{
+		gatherer: gatherer,
+		renamer:  labelRenamer,
+	}
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *labelRenamingGatherer) Gather() ([]*prometheusClient.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+
+	for _, family := range families {
+		if family.GetName() == targetInfoFamily {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			g.renameLabels(metric)
+		}
+	}
+
+	return families, err
+}
+
+func (g *labelRenamingGatherer) renameLabels(metric *prometheusClient.Metric) {
+	pairs := metric.GetLabel()
+	present := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		present[pair.GetName()] = true
+	}
+
+	renamed := false
+	for _, pair := range pairs {
+		name := pair.GetName()
+		if isReservedLabel(name) {
+			continue
+		}
+
+		newName := g.renamer.rename(name)
+		if newName == name {
+			continue
+		}
+		if !model.LabelName(newName).IsValid() || isReservedLabel(newName) || present[newName] {
+			g.warnSkipped(name, newName)
+			continue
+		}
+
+		delete(present, name)
+		present[newName] = true
+		pair.Name = &newName
+		renamed = true
+	}
+
+	if renamed {
+		slices.SortFunc(pairs, func(a, b *prometheusClient.LabelPair) int {
+			return strings.Compare(a.GetName(), b.GetName())
+		})
+	}
+}
+
+func (g *labelRenamingGatherer) warnSkipped(name string, newName string) {
+	if _, warned := g.warned.LoadOrStore(name+" -> "+newName, struct{}{}); warned {
+		return
+	}
+	slog.Warn(
+		"Skipping label rename to an invalid, reserved or already present label",
+		"label", name, "renamed", newName,
+	)
+}
+
+// isReservedLabel reports whether name is a label the exposition itself relies on.
+func isReservedLabel(name string) bool {
+	return name == model.BucketLabel || name == model.QuantileLabel ||
+		strings.HasPrefix(name, "otel_scope_") || strings.HasPrefix(name, model.ReservedLabelPrefix)
+}
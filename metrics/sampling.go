@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SampledHistogram records a random fraction of observations to reduce recording
+// cost on very hot code paths.
+//
+// Statistical implications: recorded values are not scaled, so the distribution
+// shape (bucket ratios, quantile estimates, mean) is an unbiased estimate of the true
+// one, but _count and _sum are only ~rate of the true totals and low-frequency
+// tails may be missed entirely. Quantile estimates also get noisier as rate drops.
+type SampledHistogram struct {
+	histogram metric.Float64Histogram
+	rate      float64
+}
+
+// SampledCounter adds a random fraction of increments, scaled by 1/rate, so the
+// counter stays an unbiased estimate of the true total.
+//
+// Statistical implications: the exported value is an estimate whose variance grows
+// as rate drops, it is no longer integral, and rare events (fewer than ~1/rate per
+// scrape interval) will appear as occasional large jumps rather than steady growth.
+// Don't sample counters that drive precise alerts or billing.
+type SampledCounter struct {
+	counter metric.Float64Counter
+	rate    float64
+}
+
+// SampledHistogram creates a histogram on the provider's meter that records each
+// observation with probability rate, e.g. 0.01 to record 1 in 100. Rate must be in (0, 1].
+func (p *Provider) SampledHistogram(name string, rate float64) (*SampledHistogram, error) {
+	if err := validateSampleRate(rate); err != nil {
+		return nil, err
+	}
+
+	histogram, err := p.GetMeter().Float64Histogram(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SampledHistogram{histogram: histogram, rate: rate}, nil
+}
+
+// SampledCounter creates a counter on the provider's meter that adds each increment
+// with probability rate, scaled by 1/rate. Rate must be in (0, 1].
+func (p *Provider) SampledCounter(name string, rate float64) (*SampledCounter, error) {
+	if err := validateSampleRate(rate); err != nil {
+		return nil, err
+	}
+
+	counter, err := p.GetMeter().Float64Counter(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SampledCounter{counter: counter, rate: rate}, nil
+}
+
+// Record records value if this observation is sampled.
+func (h *SampledHistogram) Record(ctx context.Context, value float64, opts ...metric.RecordOption) {
+	if !sampled(h.rate) {
+		return
+	}
+
+	h.histogram.Record(ctx, value, opts...)
+}
+
+// Add adds incr scaled by 1/rate if this increment is sampled.
+func (c *SampledCounter) Add(ctx context.Context, incr float64, opts ...metric.AddOption) {
+	if !sampled(c.rate) {
+		return
+	}
+
+	c.counter.Add(ctx, incr/c.rate, opts...)
+}
+
+func validateSampleRate(rate float64) error {
+	if rate <= 0 || rate > 1 {
+		return fmt.Errorf("sample rate must be in (0, 1], got %v", rate)
+	}
+	return nil
+}
+
+func sampled(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/domesama/doakes/config"
+	"github.com/domesama/doakes/testutil"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestSampledInstrumentsRejectInvalidRate(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	for _, rate := range []float64{0, -0.5, 1.5} {
+		if _, err := provider.SampledHistogram("sampled_histogram", rate); err == nil {
+			t.Errorf("expected error for histogram rate %v", rate)
+		}
+		if _, err := provider.SampledCounter("sampled_counter", rate); err == nil {
+			t.Errorf("expected error for counter rate %v", rate)
+		}
+	}
+}
+
+func TestSampledInstruments(t *testing.T) {
+	provider, err := NewProvider(resource.Default(), config.DefaultMetricsConfig())
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	defer provider.Cleanup()
+
+	fullHistogram, err := provider.SampledHistogram("full_histogram", 1)
+	if err != nil {
+		t.Fatalf("failed to create histogram: %v", err)
+	}
+	halfCounter, err := provider.SampledCounter("half_counter", 0.5)
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+
+	ctx := context.Background()
+	const iterations = 10000
+	for i := 0; i < iterations; i++ {
+		fullHistogram.Record(ctx, 10)
+		halfCounter.Add(ctx, 1)
+	}
+
+	m := testutil.NewInProcessHelper(provider.HTTPHandler()).ParseMetrics(t)
+	m.AssertHistogramCount(t, "full_histogram", nil, iterations)
+
+	// Each increment contributes 2 with probability 0.5 (std dev 100 over 10k adds),
+	// so the scaled total should be well within 6 sigma of the true total
+	counter := m.GetSingle(t, "half_counter_total", nil)
+	if counter == nil {
+		t.Fatal("half_counter_total not found")
+	}
+	if value := counter.GetCounter().GetValue(); value < iterations-600 || value > iterations+600 {
+		t.Errorf("expected scaled counter near %d, got %v", iterations, value)
+	}
+}
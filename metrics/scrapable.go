@@ -0,0 +1,18 @@
+package metrics
+
+import "fmt"
+
+// ScrapableCheck returns a health check that fails when the metrics endpoint's
+// registry cannot be gathered, e.g. due to a failing or inconsistent collector.
+// Register it to tie readiness to a working metrics pipeline:
+//
+//	srv.RegisterHealthCheck("metrics", metrics.ScrapableCheck(provider))
+func ScrapableCheck(provider *Provider) func() error {
+	return func() error {
+		if _, err := provider.Gatherer().Gather(); err != nil {
+			return fmt.Errorf("metrics not gatherable: %w", err)
+		}
+
+		return nil
+	}
+}
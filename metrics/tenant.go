@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TenantQueryParam selects a tenant registry on the metrics endpoint, e.g. /metrics?tenant=foo.
+const TenantQueryParam = "tenant"
+
+// tenantRegistries holds one registry and scrape handler per tenant.
+type tenantRegistries struct {
+	mutex        sync.RWMutex
+	tenants      map[string]*tenantRegistry
+	wrapGatherer func(prometheus.Gatherer) prometheus.Gatherer
+	timeout      time.Duration
+	maxInFlight  int
+}
+
+type tenantRegistry struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+}
+
+func newTenantRegistries(
+	wrapGatherer func(prometheus.Gatherer) prometheus.Gatherer,
+	timeout time.Duration,
+	maxInFlight int,
+) *tenantRegistries {
+	return &tenantRegistries{
+		tenants:      make(map[string]*tenantRegistry),
+		wrapGatherer: wrapGatherer,
+		timeout:      timeout,
+		maxInFlight:  maxInFlight,
+	}
+}
+
+// RegistryFor returns the registry for tenant, creating it on first use.
+// Collectors registered on it are served only at /metrics?tenant=<tenant>,
+// with the same exclusions and renames as the default registry.
+//
+// Tenant registries hold Prometheus collectors; OTel instruments from GetMeter
+// are always exported on the default registry.
+func (p *Provider) RegistryFor(tenant string) *prometheus.Registry {
+	return p.tenants.registryFor(tenant)
+}
+
+func (t *tenantRegistries) registryFor(tenant string) *prometheus.Registry {
+	t.mutex.RLock()
+	existing, ok := t.tenants[tenant]
+	t.mutex.RUnlock()
+	if ok {
+		return existing.registry
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if existing, ok := t.tenants[tenant]; ok {
+		return existing.registry
+	}
+
+	registry := prometheus.NewRegistry()
+	t.tenants[tenant] = &tenantRegistry{
+		registry: registry,
+		handler:  createPrometheusHTTPHandler(t.wrapGatherer(registry), t.timeout, t.maxInFlight),
+	}
+
+	return registry
+}
+
+// routingHandler serves the tenant named by TenantQueryParam, or defaultHandler when
+// no tenant is requested. Unknown tenants get 404 rather than an empty scrape.
+func (t *tenantRegistries) routingHandler(defaultHandler http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			tenant := req.URL.Query().Get(TenantQueryParam)
+			if tenant == "" {
+				defaultHandler.ServeHTTP(writer, req)
+				return
+			}
+
+			t.mutex.RLock()
+			registry, ok := t.tenants[tenant]
+			t.mutex.RUnlock()
+
+			if !ok {
+				http.Error(writer, "unknown tenant", http.StatusNotFound)
+				return
+			}
+
+			registry.handler.ServeHTTP(writer, req)
+		},
+	)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/domesama/doakes/config"
+)
+
+// debugConfig is the body served on /debug/config.
+type debugConfig struct {
+	Server  map[string]any `json:"server"`
+	Metrics map[string]any `json:"metrics"`
+}
+
+// newDebugConfigHandler serves the redacted effective configuration as JSON. Durations are
+// rendered as strings such as "15s", and fields that can't come from the environment
+// (registries, callbacks) are left out.
+func newDebugConfigHandler(
+	serverConfig config.TelemetryServerConfig,
+	metricsConfig config.MetricsConfig,
+) http.Handler {
+	body, err := json.MarshalIndent(debugConfig{
+		Server:  configFields(reflect.ValueOf(serverConfig.Redacted())),
+		Metrics: configFields(reflect.ValueOf(metricsConfig.Redacted())),
+	}, "", "  ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// configFields maps a config struct's exported fields to JSON-friendly values, skipping
+// those tagged `ignored:"true"`.
+func configFields(value reflect.Value) map[string]any {
+	fields := make(map[string]any, value.NumField())
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get("ignored") == "true" {
+			continue
+		}
+		fields[field.Name] = configValue(value.Field(i))
+	}
+	return fields
+}
+
+func configValue(value reflect.Value) any {
+	if value.Type() == durationType {
+		return time.Duration(value.Int()).String()
+	}
+
+	if value.Kind() == reflect.Struct {
+		return configFields(value)
+	}
+
+	return value.Interface()
+}
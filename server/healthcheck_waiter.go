@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"sync"
 	"time"
+
+	"github.com/domesama/doakes/config"
 )
 
 // healthCheckWaiter monitors whether EnableHealthCheck() is called within a timeout.
@@ -16,10 +18,17 @@ import (
 // This forces developers to explicitly call EnableHealthCheck() after initialization,
 // ensuring the service is truly ready. If they forget, we panic after timeout to
 // fail fast rather than silently accepting traffic too early.
+//
+// The deadline is armed when the waiter is created, which the server does only
+// after its listener is bound. Once stop() returns, the waiter never panics.
+// With the restart policy, the first expiry re-arms the deadline for another timeout.
 type healthCheckWaiter struct {
 	server       *TelemetryServer
 	timeout      time.Duration
 	pollInterval time.Duration
+	policy       config.HealthCheckTimeoutPolicy
+	deadline     time.Time
+	rearmed      bool
 
 	mutex    sync.Mutex
 	stopChan chan struct{}
@@ -27,11 +36,13 @@ type healthCheckWaiter struct {
 }
 
 func newHealthCheckWaiter(server *TelemetryServer, timeout time.Duration,
-	pollInterval time.Duration) *healthCheckWaiter {
+	pollInterval time.Duration, policy config.HealthCheckTimeoutPolicy) *healthCheckWaiter {
 	return &healthCheckWaiter{
 		server:       server,
 		timeout:      timeout,
 		pollInterval: pollInterval,
+		policy:       policy,
+		deadline:     time.Now().Add(timeout),
 		stopChan:     make(chan struct{}),
 	}
 }
@@ -40,6 +51,18 @@ func (w *healthCheckWaiter) start() {
 	go w.waitForHealthCheckEnabled()
 }
 
+// remaining returns the time left before the waiter panics, or zero once it has stopped.
+func (w *healthCheckWaiter) remaining() time.Duration {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped {
+		return 0
+	}
+
+	return max(time.Until(w.deadline), 0)
+}
+
 func (w *healthCheckWaiter) stop() {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
@@ -53,7 +76,6 @@ func (w *healthCheckWaiter) stop() {
 }
 
 func (w *healthCheckWaiter) waitForHealthCheckEnabled() {
-	deadline := time.Now().Add(w.timeout)
 	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
@@ -73,14 +95,49 @@ func (w *healthCheckWaiter) waitForHealthCheckEnabled() {
 				return
 			}
 
-			if time.Now().After(deadline) {
-				msg := "Health check not enabled within timeout - please call EnableHealthCheck()"
-				slog.Error(msg, "timeout", w.timeout)
-				panic(msg)
+			remainingTime := w.remaining()
+			if remainingTime <= 0 {
+				if w.rearm() {
+					continue
+				}
+				w.panicUnlessStopped()
+				return
 			}
 
-			remainingTime := time.Until(deadline)
 			slog.Warn("Health check still not enabled - waiting", "remaining", remainingTime)
 		}
 	}
 }
+
+// rearm extends the deadline by another timeout the first time it expires under the
+// restart policy, and reports whether it did.
+func (w *healthCheckWaiter) rearm() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.policy != config.HealthCheckTimeoutPolicyRestart || w.rearmed {
+		return false
+	}
+
+	w.rearmed = true
+	w.deadline = time.Now().Add(w.timeout)
+	slog.Warn("Health check not enabled within timeout - allowing one more grace period", "timeout", w.timeout)
+
+	return true
+}
+
+// panicUnlessStopped panics unless stop() was called, the server stopped, or health
+// checks were enabled in the meantime. Holding the mutex means a concurrent stop() either finishes first
+// (no panic) or waits, so the panic can't fire after stop() returns.
+func (w *healthCheckWaiter) panicUnlessStopped() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped || !w.server.IsRunning() || w.server.IsHealthCheckEnabled() {
+		return
+	}
+
+	msg := "Health check not enabled within timeout - please call EnableHealthCheck()"
+	slog.Error(msg, "timeout", w.timeout)
+	panic(msg)
+}
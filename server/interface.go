@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/domesama/doakes/config"
+	"github.com/domesama/doakes/healthcheck"
+	"github.com/domesama/doakes/metrics"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Server is the part of TelemetryServer that application code registers against.
+// Depend on it instead of *TelemetryServer to inject servertest.Fake in unit tests.
+type Server interface {
+	RegisterHealthCheck(name string, checkFn healthcheck.CheckFunction)
+	RegisterHealthCheckWithOptions(name string, checkFn healthcheck.CheckFunction, options healthcheck.CheckOptions)
+	SetHealthCheckEnabled(name string, enabled bool)
+	EnableHealthCheck()
+	IsHealthCheckEnabled() bool
+	GetMeter() metric.Meter
+}
+
+// TelemetryServerAPI is the full public API of TelemetryServer, including its lifecycle,
+// for code that owns the server but should not depend on the concrete type.
+// Engine() is deliberately left out since it exposes gin.
+type TelemetryServerAPI interface {
+	Server
+
+	ExpectHealthChecks(names ...string)
+	SetHealthCheckAggregation(aggregation healthcheck.Aggregation)
+	OnHealthStatusChange(fn healthcheck.StatusChangeFunc)
+	SetDraining(draining bool)
+	BindReadinessToContext(ctx context.Context) (stop func() bool)
+	TimeUntilHealthCheckTimeout() time.Duration
+	Healthy() bool
+	HealthReport() healthcheck.GroupsReport
+	SetGlobalLabel(name string, value string) error
+
+	Start() error
+	StartWithAddress(address string) error
+	Stop() error
+	StopContext(ctx context.Context) error
+	StopWithReason(reason string) error
+	StopWithReasonContext(ctx context.Context, reason string) error
+	DrainAndStop(ctx context.Context, gracePeriod time.Duration) error
+
+	Config() config.TelemetryServerConfig
+	MetricsProvider() *metrics.Provider
+	IsRunning() bool
+	StartTime() time.Time
+	GetRunningAddress() string
+	GetRunningPort() int
+	WaitForPort(ctx context.Context) (int, error)
+	GetRunningGRPCHealthAddress() string
+	GetRunningHealthAddress() string
+}
+
+var (
+	_ Server             = (*TelemetryServer)(nil)
+	_ TelemetryServerAPI = (*TelemetryServer)(nil)
+)
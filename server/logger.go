@@ -0,0 +1,20 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+)
+
+// builtinSlogHandler is slog's own default handler, captured before main runs so
+// installDefaultLogger can tell whether the application configured one.
+var builtinSlogHandler = slog.Default().Handler()
+
+// installDefaultLogger makes a JSON handler writing to stderr the slog default, unless
+// the application already replaced slog's built-in handler.
+func installDefaultLogger() {
+	if slog.Default().Handler() != builtinSlogHandler {
+		return
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"time"
+)
+
+// ErrUnknownProfile is returned by CaptureProfile for a name not in ProfileNames.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// CaptureCPUProfile records a CPU profile for d and returns it in the gzipped protobuf
+// format served by /debug/pprof/profile, for shipping profiles from ops automation without
+// scraping the endpoint. Only one CPU profile can run per process at a time, so it fails
+// while another one, including one requested over HTTP, is in progress.
+func CaptureCPUProfile(d time.Duration) ([]byte, error) {
+	var profile bytes.Buffer
+	if err := pprof.StartCPUProfile(&profile); err != nil {
+		return nil, err
+	}
+
+	time.Sleep(d)
+	pprof.StopCPUProfile()
+
+	return profile.Bytes(), nil
+}
+
+// CaptureHeapProfile returns a heap profile as of the most recently completed garbage
+// collection, in the same format as /debug/pprof/heap.
+func CaptureHeapProfile() ([]byte, error) {
+	var profile bytes.Buffer
+	if err := pprof.WriteHeapProfile(&profile); err != nil {
+		return nil, err
+	}
+
+	return profile.Bytes(), nil
+}
+
+// ProfileNames lists the profiles CaptureProfile accepts, e.g. "goroutine", "heap",
+// "allocs", "block", "mutex" and "threadcreate".
+func ProfileNames() []string {
+	profiles := pprof.Profiles()
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name())
+	}
+	return names
+}
+
+// CaptureProfile returns a snapshot of the named profile in the gzipped protobuf format.
+// It returns ErrUnknownProfile for a name not in ProfileNames.
+func CaptureProfile(name string) ([]byte, error) {
+	profile := pprof.Lookup(name)
+	if profile == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownProfile, name)
+	}
+
+	var snapshot bytes.Buffer
+	if err := profile.WriteTo(&snapshot, 0); err != nil {
+		return nil, err
+	}
+
+	return snapshot.Bytes(), nil
+}
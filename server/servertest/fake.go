@@ -0,0 +1,124 @@
+// Package servertest provides a fake server.Server for unit tests that don't need a real telemetry server.
+package servertest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/domesama/doakes/healthcheck"
+	"github.com/domesama/doakes/server"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// Fake is an in-memory server.Server. It records registered checks so tests can
+// assert on them or run them, and hands out a no-op meter. The zero value is ready to use.
+type Fake struct {
+	mutex   sync.Mutex
+	checks  map[string]healthcheck.CheckFunction
+	options map[string]healthcheck.CheckOptions
+	muted   map[string]bool
+	enabled bool
+}
+
+var _ server.Server = (*Fake)(nil)
+
+// RegisterHealthCheck records the check under name.
+func (f *Fake) RegisterHealthCheck(name string, checkFn healthcheck.CheckFunction) {
+	f.RegisterHealthCheckWithOptions(name, checkFn, healthcheck.CheckOptions{})
+}
+
+// RegisterHealthCheckWithOptions records the check and its options under name.
+func (f *Fake) RegisterHealthCheckWithOptions(
+	name string,
+	checkFn healthcheck.CheckFunction,
+	options healthcheck.CheckOptions,
+) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.checks == nil {
+		f.checks = make(map[string]healthcheck.CheckFunction)
+		f.options = make(map[string]healthcheck.CheckOptions)
+		f.muted = make(map[string]bool)
+	}
+	f.checks[name] = checkFn
+	f.options[name] = options
+	delete(f.muted, name)
+}
+
+// SetHealthCheckEnabled records whether the named check is muted. Unknown names are ignored.
+func (f *Fake) SetHealthCheckEnabled(name string, enabled bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.checks[name]; ok {
+		f.muted[name] = !enabled
+	}
+}
+
+// EnableHealthCheck records that health checks were enabled.
+func (f *Fake) EnableHealthCheck() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.enabled = true
+}
+
+// IsHealthCheckEnabled reports whether EnableHealthCheck was called.
+func (f *Fake) IsHealthCheckEnabled() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.enabled
+}
+
+// GetMeter returns a no-op meter.
+func (f *Fake) GetMeter() metric.Meter {
+	return noop.NewMeterProvider().Meter("servertest")
+}
+
+// CheckNames returns the names of the registered checks, sorted.
+func (f *Fake) CheckNames() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	names := make([]string, 0, len(f.checks))
+	for name := range f.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CheckOptions returns the options the named check was registered with.
+func (f *Fake) CheckOptions(name string) (healthcheck.CheckOptions, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	options, ok := f.options[name]
+	return options, ok
+}
+
+// IsCheckMuted reports whether the named check was muted via SetHealthCheckEnabled.
+func (f *Fake) IsCheckMuted(name string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.muted[name]
+}
+
+// RunCheck runs the named check and returns its result.
+func (f *Fake) RunCheck(name string) error {
+	f.mutex.Lock()
+	checkFn, ok := f.checks[name]
+	f.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("health check %q not registered", name)
+	}
+
+	return checkFn()
+}
@@ -0,0 +1,49 @@
+package servertest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/domesama/doakes/healthcheck"
+	"github.com/domesama/doakes/server"
+	"github.com/domesama/doakes/server/servertest"
+	"github.com/stretchr/testify/assert"
+)
+
+// registerDependencies stands in for application code that depends on server.Server.
+func registerDependencies(srv server.Server) error {
+	srv.RegisterHealthCheck("cache", func() error { return nil })
+	srv.RegisterHealthCheckWithOptions(
+		"database", func() error { return errors.New("connection refused") },
+		healthcheck.CheckOptions{Description: "Primary database"},
+	)
+
+	counter, err := srv.GetMeter().Int64Counter("jobs_processed")
+	if err != nil {
+		return err
+	}
+	counter.Add(context.Background(), 1)
+
+	srv.EnableHealthCheck()
+	return nil
+}
+
+func TestFake(t *testing.T) {
+	fake := &servertest.Fake{}
+
+	assert.NoError(t, registerDependencies(fake))
+
+	assert.Equal(t, []string{"cache", "database"}, fake.CheckNames())
+	assert.True(t, fake.IsHealthCheckEnabled())
+	assert.NoError(t, fake.RunCheck("cache"))
+	assert.EqualError(t, fake.RunCheck("database"), "connection refused")
+	assert.Error(t, fake.RunCheck("missing"))
+
+	options, ok := fake.CheckOptions("database")
+	assert.True(t, ok)
+	assert.Equal(t, "Primary database", options.Description)
+
+	fake.SetHealthCheckEnabled("database", false)
+	assert.True(t, fake.IsCheckMuted("database"))
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ShutdownMetricName is the counter recorded by StopWithReason, labelled by reason.
+const ShutdownMetricName = "service_shutdown_total"
+
+// Common shutdown reasons for StopWithReason. Any string may be used.
+const (
+	ShutdownReasonSignal = "signal"
+	ShutdownReasonError  = "error"
+	ShutdownReasonManual = "manual"
+)
+
+func newShutdownCounter(meter metric.Meter) (metric.Int64Counter, error) {
+	return meter.Int64Counter(
+		ShutdownMetricName,
+		metric.WithDescription("Shutdowns of the service by reason, recorded right before exit"),
+	)
+}
+
+// StopWithReason records service_shutdown_total{reason} and force-flushes it to push-based
+// exporters (OTLP, Pushgateway and remote write push once more on stop) before stopping
+// the server, so dashboards can tell clean deploys from crashes. It does nothing if the
+// server is not running.
+func (s *TelemetryServer) StopWithReason(reason string) error {
+	return s.StopWithReasonContext(context.Background(), reason)
+}
+
+// StopWithReasonContext is StopWithReason bounded by ctx, as StopContext is.
+func (s *TelemetryServer) StopWithReasonContext(ctx context.Context, reason string) error {
+	if !s.IsRunning() {
+		return nil
+	}
+
+	slog.Info("Stopping internal telemetry server", "reason", reason)
+	s.shutdownCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+
+	if err := s.metricsProvider.ForceFlush(ctx); err != nil {
+		slog.Warn("Failed to flush metrics before shutdown", "error", err)
+	}
+
+	return s.StopContext(ctx)
+}
@@ -1,34 +1,75 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/domesama/doakes/config"
+	"github.com/domesama/doakes/grpchealth"
 	"github.com/domesama/doakes/healthcheck"
+	"github.com/domesama/doakes/healthcheck/checks"
 	"github.com/domesama/doakes/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 
 	internalhttp "github.com/domesama/doakes/http"
 )
 
+var (
+	// ErrMetricsProviderInit is returned by New when the metrics provider cannot be created.
+	ErrMetricsProviderInit = errors.New("failed to create metrics provider")
+	// ErrListen is returned by Start when the listen address cannot be bound.
+	ErrListen = errors.New("failed to listen")
+	// ErrAlreadyRunning is returned by Start when the server has already been started.
+	ErrAlreadyRunning = errors.New("telemetry server already running")
+	// ErrHealthCheckMetricsInit is returned by New when the health check metrics cannot be registered.
+	ErrHealthCheckMetricsInit = errors.New("failed to register health check metrics")
+	// ErrShutdownMetricInit is returned by New when the shutdown counter cannot be created.
+	ErrShutdownMetricInit = errors.New("failed to create shutdown metric")
+	// ErrTLSInit is returned by New when the TLS certificate, key or client CA cannot be loaded.
+	ErrTLSInit = errors.New("failed to load tls configuration")
+)
+
+// ReadinessFileCheckName is the check registered for TelemetryServerConfig.ReadinessFile.
+const ReadinessFileCheckName = "readiness-file"
+
+// ReadinessMinUptimeCheckName is the check registered for TelemetryServerConfig.ReadinessMinUptime.
+const ReadinessMinUptimeCheckName = "min-uptime"
+
 // TelemetryServer manages the internal observability server that exposes metrics,
 // health checks, and profiling endpoints.
 type TelemetryServer struct {
 	config          config.TelemetryServerConfig
+	router          *gin.Engine
 	httpServer      *internalhttp.Server
 	healthCheck     *healthcheck.Handler
 	metricsProvider *metrics.Provider
+	// grpcHealthServer is nil unless GRPCHealthListenAddress is configured
+	grpcHealthServer *grpchealth.Server
+	// healthServer is nil unless HealthListenAddress is configured
+	healthServer    *internalhttp.Server
+	shutdownCounter metric.Int64Counter
 
 	mutex   sync.RWMutex
 	running bool
+	// startedAt is when the latest successful Start bound its listener
+	startedAt time.Time
+	// startDone is closed once the latest Start has bound its listener or failed,
+	// so a concurrent Stop never shuts down a server that is still binding
+	startDone chan struct{}
 	// healthCheckWaiter monitors if EnableHealthCheck() is called within timeout
 	// to prevent services from passing health checks before they're ready
 	healthCheckWaiter *healthCheckWaiter
@@ -40,43 +81,140 @@ type Options struct {
 	MetricsConfig         config.MetricsConfig
 	TelemetryServerConfig config.TelemetryServerConfig
 	ServiceName           string
-	ServiceVersion        string
+	// ServiceVersion is shown on the index endpoint when the resource has no service.version.
+	// Empty leaves the version empty rather than reporting a placeholder.
+	ServiceVersion string
+	// IndexFormatter customizes the index response, e.g. to rename its JSON fields.
+	// Nil serves http.IndexInfo from github.com/domesama/doakes/http.
+	IndexFormatter internalhttp.IndexFormatter
+	// ServiceNameFallback is used when the resource has no service name, for both the
+	// index endpoint and this server's meter scope (see config.MetricsConfig.ServiceNameFallback).
+	// It doesn't change the process-wide metrics.ServiceNameFallback.
+	// Defaults to "unknown-service.name" for the server and "unknown-service" for meters.
+	ServiceNameFallback string
+	// InstallDefaultLogger makes a JSON slog handler writing to stderr the process-wide
+	// default, so startup and health check logs are machine-parseable. It does nothing
+	// if the application already called slog.SetDefault.
+	InstallDefaultLogger bool
+	// HealthCheckErrorSanitizer, when set, transforms check errors before they appear in
+	// health responses, e.g. to strip credentials (see healthcheck.Handler.SetErrorSanitizer).
+	HealthCheckErrorSanitizer healthcheck.ErrorSanitizer
 }
 
 // New creates a new TelemetryServer with the provided options.
 // It initializes the metrics provider, HTTP server, and health check handler.
 func New(opts Options) (*TelemetryServer, error) {
+	if opts.InstallDefaultLogger {
+		installDefaultLogger()
+	}
+
 	if opts.Resource == nil {
 		opts.Resource = resource.Default()
 	}
 
-	serviceName := ExtracResourceByKey(semconv.ServiceNameKey, opts.Resource)
-	serviceVersion := ExtracResourceByKey(semconv.ServiceVersionKey, opts.Resource)
+	serviceName, ok := ExtractResourceByKeyOK(semconv.ServiceNameKey, opts.Resource)
+	if !ok {
+		serviceName = resourceFallback(semconv.ServiceNameKey, opts.ServiceNameFallback)
+	}
+	serviceVersion, ok := ExtractResourceByKeyOK(semconv.ServiceVersionKey, opts.Resource)
+	if !ok {
+		serviceVersion = opts.ServiceVersion
+	}
 
 	healthCheckHandler := internalhttp.NewHealthCheckHandler(serviceName)
+	healthCheckHandler.SetTimeout(opts.TelemetryServerConfig.HealthCheckTimeout)
+	healthCheckHandler.SetSlowThreshold(opts.TelemetryServerConfig.HealthCheckSlowThreshold)
+	healthCheckHandler.SetCache(
+		opts.TelemetryServerConfig.HealthCheckCacheTTL,
+		opts.TelemetryServerConfig.HealthCheckCacheJitter,
+	)
+	healthCheckHandler.SetConcurrency(opts.TelemetryServerConfig.HealthCheckConcurrency)
+	healthCheckHandler.SetErrorSanitizer(opts.HealthCheckErrorSanitizer)
+	healthCheckHandler.SetFailureDetail(healthcheck.FailureDetail(opts.TelemetryServerConfig.HealthCheckFailureDetail))
+	if readinessFile := opts.TelemetryServerConfig.ReadinessFile; readinessFile != "" {
+		healthCheckHandler.RegisterCheckWithOptions(
+			ReadinessFileCheckName, checks.FileExistsCheck(readinessFile),
+			healthcheck.CheckOptions{Description: "Readiness file " + readinessFile + " exists"},
+		)
+	}
 
+	if opts.MetricsConfig.ServiceNameFallback == "" {
+		opts.MetricsConfig.ServiceNameFallback = opts.ServiceNameFallback
+	}
 	metricsProvider, err := metrics.NewProvider(opts.Resource, opts.MetricsConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create metrics provider: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrMetricsProviderInit, err)
+	}
+
+	if err := healthCheckHandler.RegisterMetrics(metricsProvider.GetMeter()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHealthCheckMetricsInit, err)
+	}
+
+	shutdownCounter, err := newShutdownCounter(metricsProvider.GetMeter())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrShutdownMetricInit, err)
 	}
 
-	indexHandler := internalhttp.CreateIndexHandler(serviceName, serviceVersion)
+	indexHandler := internalhttp.CreateIndexHandlerWithFormatter(serviceName, serviceVersion, opts.IndexFormatter)
+
+	tlsConfig, err := loadTLSConfig(opts.TelemetryServerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTLSInit, err)
+	}
 
-	router := internalhttp.NewRouter(
-		internalhttp.RouterConfig{
-			HealthCheckHandler: healthCheckHandler,
-			MetricsHandler:     metricsProvider.HTTPHandler(),
-			IndexHandler:       indexHandler,
+	routerConfig := internalhttp.RouterConfig{
+		HealthCheckHandler:      healthCheckHandler,
+		HealthCheckAliases:      opts.TelemetryServerConfig.HealthCheckPathAliases,
+		HealthCheckWatchHandler: healthCheckHandler.WatchHandler(),
+		HealthCheckListHandler:  healthCheckHandler.ChecksHandler(),
+		LivenessHandler:         healthCheckHandler.LivenessHandler(),
+		ReadinessHandler:        healthCheckHandler.ReadinessHandler(),
+		MetricsHandler:          metricsProvider.HTTPHandler(),
+		IndexHandler:            indexHandler,
+		TrustedProxies:          opts.TelemetryServerConfig.TrustedProxies,
+		Mode:                    opts.TelemetryServerConfig.GinMode,
+		MetricsToken:            opts.TelemetryServerConfig.MetricsToken,
+		Meter:                   metricsProvider.GetMeter(),
+		ConfigHandler:           newDebugConfigHandler(opts.TelemetryServerConfig, opts.MetricsConfig),
+		HealthRouteTimeout:      opts.TelemetryServerConfig.HealthRouteTimeout,
+		MetricsRouteTimeout:     opts.TelemetryServerConfig.MetricsRouteTimeout,
+		ProfilingRouteTimeout:   opts.TelemetryServerConfig.ProfilingRouteTimeout,
+	}
+	router := internalhttp.NewRouter(routerConfig)
+
+	httpServer := internalhttp.NewServer(
+		router,
+		internalhttp.ServerConfig{
+			MaxHeaderBytes:         opts.TelemetryServerConfig.MaxHeaderBytes,
+			Network:                opts.TelemetryServerConfig.ListenNetwork,
+			ProfilingShutdownGrace: opts.TelemetryServerConfig.ProfilingShutdownGrace,
+			TLSConfig:              tlsConfig,
 		},
 	)
 
-	httpServer := internalhttp.NewServer(router)
-
 	server := &TelemetryServer{
 		config:          opts.TelemetryServerConfig,
+		router:          router,
 		httpServer:      httpServer,
 		healthCheck:     healthCheckHandler,
 		metricsProvider: metricsProvider,
+		shutdownCounter: shutdownCounter,
+		healthServer:    newHealthServer(opts.TelemetryServerConfig, routerConfig),
+	}
+
+	if minUptime := opts.TelemetryServerConfig.ReadinessMinUptime; minUptime > 0 {
+		healthCheckHandler.RegisterCheckWithOptions(
+			ReadinessMinUptimeCheckName, server.minUptimeCheck(minUptime),
+			healthcheck.CheckOptions{Description: "Up for at least " + minUptime.String()},
+		)
+	}
+
+	if opts.TelemetryServerConfig.GRPCHealthListenAddress != "" {
+		server.grpcHealthServer = grpchealth.NewServer(
+			healthCheckHandler,
+			opts.TelemetryServerConfig.GRPCHealthWatchInterval,
+		)
+		server.grpcHealthServer.SetNetwork(opts.TelemetryServerConfig.ListenNetwork)
 	}
 
 	return server, nil
@@ -88,6 +226,41 @@ func (s *TelemetryServer) RegisterHealthCheck(name string, checkFn healthcheck.C
 	s.healthCheck.RegisterCheck(name, checkFn)
 }
 
+// RegisterHealthCheckWithOptions adds a health check with metadata such as a description,
+// surfaced in /_hc/checks and detailed reports.
+func (s *TelemetryServer) RegisterHealthCheckWithOptions(
+	name string,
+	checkFn healthcheck.CheckFunction,
+	options healthcheck.CheckOptions,
+) {
+	s.healthCheck.RegisterCheckWithOptions(name, checkFn, options)
+}
+
+// ExpectHealthChecks declares health checks that fail until they are registered.
+// See healthcheck.Handler.ExpectChecks for details.
+func (s *TelemetryServer) ExpectHealthChecks(names ...string) {
+	s.healthCheck.ExpectChecks(names...)
+}
+
+// SetHealthCheckEnabled mutes (false) or unmutes (true) a registered health check.
+// See healthcheck.Handler.SetCheckEnabled for details.
+func (s *TelemetryServer) SetHealthCheckEnabled(name string, enabled bool) {
+	s.healthCheck.SetCheckEnabled(name, enabled)
+}
+
+// SetHealthCheckAggregation sets how check results combine into the aggregate status,
+// e.g. healthcheck.AggregateAny for redundant backends. Defaults to healthcheck.AggregateAll.
+func (s *TelemetryServer) SetHealthCheckAggregation(aggregation healthcheck.Aggregation) {
+	s.healthCheck.SetAggregation(aggregation)
+}
+
+// OnHealthStatusChange registers fn to be called when the aggregate health status
+// transitions, e.g. to notify on-call channels when readiness flips.
+// See healthcheck.Handler.OnStatusChange for details.
+func (s *TelemetryServer) OnHealthStatusChange(fn healthcheck.StatusChangeFunc) {
+	s.healthCheck.OnStatusChange(fn)
+}
+
 // EnableHealthCheck activates the health check endpoint.
 // This must be called after registration or the endpoint will return 503.
 // This is intentional to prevent premature health check passes during startup.
@@ -95,11 +268,80 @@ func (s *TelemetryServer) EnableHealthCheck() {
 	s.healthCheck.Enable()
 }
 
+// SetDraining marks health checks as draining, failing them without running the
+// registered checks. Call it before Stop so load balancers stop routing traffic first.
+func (s *TelemetryServer) SetDraining(draining bool) {
+	s.healthCheck.SetDraining(draining)
+}
+
+// BindReadinessToContext starts draining health checks once ctx is done, see
+// healthcheck.Handler.BindReadinessToContext. The returned stop unbinds ctx.
+func (s *TelemetryServer) BindReadinessToContext(ctx context.Context) (stop func() bool) {
+	return s.healthCheck.BindReadinessToContext(ctx)
+}
+
 // IsHealthCheckEnabled returns true if health checks are enabled.
 func (s *TelemetryServer) IsHealthCheckEnabled() bool {
 	return s.healthCheck.IsEnabled()
 }
 
+// Healthy runs the registered checks once, exactly as /_hc does, and reports whether they
+// pass. It is false before EnableHealthCheck() and while draining, so background workers
+// can gate themselves on the same health definition as the probe.
+func (s *TelemetryServer) Healthy() bool {
+	return s.healthCheck.Evaluate() == nil
+}
+
+// HealthReport evaluates liveness and readiness once, exactly as /_hc?all=true does, and
+// returns the result for callers serving health in their own format (e.g. on Engine()).
+func (s *TelemetryServer) HealthReport() healthcheck.GroupsReport {
+	return s.healthCheck.EvaluateGroups()
+}
+
+// SetGlobalLabel adds name=value to every exposed series from now on, e.g. a shard id
+// assigned after startup. See metrics.Provider.SetGlobalLabel for details.
+func (s *TelemetryServer) SetGlobalLabel(name string, value string) error {
+	return s.metricsProvider.SetGlobalLabel(name, value)
+}
+
+// Config returns a copy of the configuration the server was created with, e.g. to confirm
+// what environment parsing produced.
+func (s *TelemetryServer) Config() config.TelemetryServerConfig {
+	serverConfig := s.config
+	serverConfig.TrustedProxies = slices.Clone(s.config.TrustedProxies)
+	serverConfig.HealthCheckPathAliases = slices.Clone(s.config.HealthCheckPathAliases)
+	return serverConfig
+}
+
+// MetricsProvider returns the metrics provider behind /metrics, e.g. for metrics.ScrapableCheck.
+func (s *TelemetryServer) MetricsProvider() *metrics.Provider {
+	return s.metricsProvider
+}
+
+// GetMeter returns a Meter scoped to the service name from the metrics provider.
+func (s *TelemetryServer) GetMeter() metric.Meter {
+	return s.metricsProvider.GetMeter()
+}
+
+// TimeUntilHealthCheckTimeout returns how long is left to call EnableHealthCheck()
+// before the server panics. Returns zero if the server hasn't started,
+// health checks are already enabled, or the server has stopped.
+func (s *TelemetryServer) TimeUntilHealthCheckTimeout() time.Duration {
+	if s.IsHealthCheckEnabled() {
+		return 0
+	}
+
+	s.mutex.RLock()
+	waiter := s.healthCheckWaiter
+	s.mutex.RUnlock()
+
+	if waiter == nil {
+		return 0
+	}
+
+	return waiter.remaining()
+}
+
 // Start begins serving HTTP requests on the configured address.
 func (s *TelemetryServer) Start() error {
 	return s.StartWithAddress(s.config.ListenAddress)
@@ -107,22 +349,76 @@ func (s *TelemetryServer) Start() error {
 
 // StartWithAddress begins serving HTTP requests on the specified address.
 // The health check watcher will start monitoring for EnableHealthCheck() calls.
+// Returns ErrAlreadyRunning if the server has already been started.
 func (s *TelemetryServer) StartWithAddress(address string) error {
 	s.mutex.Lock()
 	if s.running {
 		s.mutex.Unlock()
 		slog.Info("TelemetryServer already running", "address", address)
-		return nil
+		return ErrAlreadyRunning
 	}
 	s.running = true
+	startDone := make(chan struct{})
+	s.startDone = startDone
 	s.mutex.Unlock()
+	defer close(startDone)
+
+	slog.Info(
+		"Starting internal telemetry server",
+		"address", address,
+		"listen_network", s.config.ListenNetwork,
+		"health_check_enable_timeout", s.config.HealthCheckEnableTimeout,
+		"health_check_poll_interval", s.config.HealthCheckPollInterval,
+		"health_check_timeout_policy", s.config.HealthCheckTimeoutPolicy,
+		"health_check_timeout", s.config.HealthCheckTimeout,
+		"drain_grace", s.config.DrainGracePeriod,
+		"fallback_to_ephemeral_port", s.config.FallbackToEphemeralPort,
+		"grpc_health_address", s.config.GRPCHealthListenAddress,
+		"health_address", s.config.HealthListenAddress,
+		"tls", s.config.TLSCertFile != "",
+		"mtls", s.config.ClientCAFile != "",
+		"metrics_token_set", s.config.MetricsToken != "",
+	)
 
-	slog.Info("Starting internal telemetry server", "address", address)
+	if err := s.listen(address); err != nil {
+		s.mutex.Lock()
+		s.running = false
+		s.mutex.Unlock()
+		return fmt.Errorf("%w on %s: %w", ErrListen, address, err)
+	}
+
+	// Only listeners are bound until every one has succeeded, so a failed start closes
+	// them and leaves the servers able to start again on a retry
+	if err := s.listenHealthServer(); err != nil {
+		_ = s.httpServer.CloseListener()
+		s.mutex.Lock()
+		s.running = false
+		s.mutex.Unlock()
+		return fmt.Errorf("%w on %s: %w", ErrListen, s.config.HealthListenAddress, err)
+	}
+
+	if err := s.startGRPCHealthServer(); err != nil {
+		if s.healthServer != nil {
+			_ = s.healthServer.CloseListener()
+		}
+		_ = s.httpServer.CloseListener()
+		s.mutex.Lock()
+		s.running = false
+		s.mutex.Unlock()
+		return fmt.Errorf("%w on %s: %w", ErrListen, s.config.GRPCHealthListenAddress, err)
+	}
+	s.serveHealthServer()
+
+	s.mutex.Lock()
+	s.startedAt = time.Now()
+	startedAt := s.startedAt
+	s.mutex.Unlock()
+	s.healthCheck.SetStartTime(startedAt)
 
 	s.startHealthCheckWatcher()
 
 	go func() {
-		err := s.httpServer.Start(address)
+		err := s.httpServer.Serve()
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("TelemetryServer failed", "error", err)
 			panic(err)
@@ -132,31 +428,164 @@ func (s *TelemetryServer) StartWithAddress(address string) error {
 	return nil
 }
 
+// listen binds the first available of the comma-separated addresses, falling back to an
+// OS-assigned port on the first address's host when all are in use and
+// FallbackToEphemeralPort is enabled.
+func (s *TelemetryServer) listen(address string) error {
+	candidates := config.SplitListenAddresses(address)
+
+	var err error
+	for i, candidate := range candidates {
+		if err = s.httpServer.Listen(candidate); err == nil {
+			if len(candidates) > 1 {
+				slog.Info("Internal telemetry server bound", "address", candidate, "candidate", i+1)
+			}
+			return nil
+		}
+		if len(candidates) > 1 {
+			slog.Warn("Cannot bind listen address - trying the next one", "address", candidate, "error", err)
+		}
+	}
+
+	if !s.config.FallbackToEphemeralPort || !errors.Is(err, syscall.EADDRINUSE) {
+		return err
+	}
+
+	host, _, splitErr := net.SplitHostPort(candidates[0])
+	if splitErr != nil {
+		return err
+	}
+
+	slog.Warn("Listen address already in use - falling back to an ephemeral port", "address", address)
+
+	if err := s.httpServer.Listen(net.JoinHostPort(host, "0")); err != nil {
+		return err
+	}
+
+	slog.Info("Internal telemetry server bound to ephemeral port", "address", s.httpServer.ActualAddress())
+	return nil
+}
+
+// StartTime returns when the latest successful Start bound its listener, the canonical
+// "serving since" time behind the enable-delay metric and the min-uptime check. It is
+// the zero time until the server has started.
+func (s *TelemetryServer) StartTime() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.startedAt
+}
+
+// minUptimeCheck fails until the server has been running for minUptime, so readiness
+// can't pass before connection pools and caches have had time to warm up.
+func (s *TelemetryServer) minUptimeCheck(minUptime time.Duration) healthcheck.CheckFunction {
+	return func() error {
+		startedAt := s.StartTime()
+		if startedAt.IsZero() {
+			return errors.New("server not started")
+		}
+		if uptime := time.Since(startedAt); uptime < minUptime {
+			return fmt.Errorf("up for %s, ready after %s", uptime.Round(time.Millisecond), minUptime)
+		}
+		return nil
+	}
+}
+
 // Stop gracefully shuts down the server.
 // It stops the HTTP server, metrics provider, and health check watcher.
 func (s *TelemetryServer) Stop() error {
+	return s.StopContext(context.Background())
+}
+
+// DrainAndStop marks health checks as draining, waits gracePeriod (or until ctx is done)
+// for load balancers to notice, then stops the server. A zero gracePeriod, or a server
+// that isn't running, stops immediately.
+func (s *TelemetryServer) DrainAndStop(ctx context.Context, gracePeriod time.Duration) error {
+	if gracePeriod > 0 && s.IsRunning() {
+		s.SetDraining(true)
+		slog.Info("Draining internal telemetry server", "grace_period", gracePeriod)
+
+		timer := time.NewTimer(gracePeriod)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	return s.StopContext(ctx)
+}
+
+// StopContext is like Stop, but waits for in-flight requests only until ctx is done.
+// The metrics dump and final pushes still run when ctx ends first.
+func (s *TelemetryServer) StopContext(ctx context.Context) error {
 	s.mutex.Lock()
 	if !s.running {
 		s.mutex.Unlock()
 		return nil
 	}
 	s.running = false
+	startDone := s.startDone
 	s.mutex.Unlock()
 
+	// Wait for a concurrent Start to finish binding, or Shutdown would miss its listener.
+	// Binding doesn't block, so this ignores ctx rather than return with running already
+	// cleared and the server left serving
+	if startDone != nil {
+		<-startDone
+	}
+
 	s.stopHealthCheckWatcher()
 
 	slog.Info("Shutting down internal telemetry server")
 
-	if err := s.httpServer.Shutdown(); err != nil {
-		return err
+	if s.grpcHealthServer != nil {
+		s.grpcHealthServer.StopContext(ctx)
+	}
+
+	if s.healthServer != nil {
+		if err := s.healthServer.ShutdownContext(ctx); err != nil {
+			slog.Warn("Failed to shut down health listener", "error", err)
+		}
+	}
+
+	// Dump and clean up even when ctx ended before in-flight requests did, so the final
+	// pushes and the dump aren't lost; Cleanup bounds its exporters' shutdown itself
+	shutdownErr := s.httpServer.ShutdownContext(ctx)
+
+	if path := s.config.MetricsDumpOnShutdownPath; path != "" {
+		s.dumpMetrics(path)
 	}
 
 	s.metricsProvider.Cleanup()
 
+	if shutdownErr != nil {
+		return shutdownErr
+	}
+
 	slog.Info("internal telemetry server stopped")
 	return nil
 }
 
+// dumpMetrics writes the final /metrics output to path in the Prometheus text format.
+// The file is written atomically, so a collector never reads a partial dump.
+func (s *TelemetryServer) dumpMetrics(path string) {
+	if err := prometheus.WriteToTextfile(path, s.metricsProvider.Gatherer()); err != nil {
+		slog.Error("Failed to dump metrics on shutdown", "path", path, "error", err)
+		return
+	}
+
+	slog.Info("Dumped metrics on shutdown", "path", path)
+}
+
+// Engine returns the underlying gin engine for advanced customization such as
+// trusted proxies, MaxMultipartMemory or a custom NoRoute handler.
+// Configure it before Start. Adding routes that conflict with the built-in ones
+// (/, /_hc, /metrics, /debug/pprof) panics or breaks them; such misuse is at the caller's risk.
+func (s *TelemetryServer) Engine() *gin.Engine {
+	return s.router
+}
+
 // IsRunning returns true if the server is currently running.
 func (s *TelemetryServer) IsRunning() bool {
 	s.mutex.RLock()
@@ -193,31 +622,94 @@ func (s *TelemetryServer) GetRunningPort() int {
 	return portNum
 }
 
+// WaitForPort blocks until the server is listening and returns its port,
+// or returns ctx's error if ctx is done first.
+func (s *TelemetryServer) WaitForPort(ctx context.Context) (int, error) {
+	select {
+	case <-s.httpServer.Listening():
+		return s.GetRunningPort(), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// GetRunningGRPCHealthAddress returns the address the gRPC health server is listening on.
+// Returns empty string if the gRPC health server is disabled or hasn't started yet.
+func (s *TelemetryServer) GetRunningGRPCHealthAddress() string {
+	if s.grpcHealthServer == nil {
+		return ""
+	}
+	return s.grpcHealthServer.ActualAddress()
+}
+
+func (s *TelemetryServer) startGRPCHealthServer() error {
+	if s.grpcHealthServer == nil {
+		return nil
+	}
+	return s.grpcHealthServer.Start(s.config.GRPCHealthListenAddress)
+}
+
 func (s *TelemetryServer) startHealthCheckWatcher() {
-	s.healthCheckWaiter = newHealthCheckWaiter(
+	waiter := newHealthCheckWaiter(
 		s,
 		s.config.HealthCheckEnableTimeout,
 		s.config.HealthCheckPollInterval,
+		s.config.HealthCheckTimeoutPolicy,
 	)
-	s.healthCheckWaiter.start()
+
+	s.mutex.Lock()
+	s.healthCheckWaiter = waiter
+	s.mutex.Unlock()
+
+	waiter.start()
 }
 
 func (s *TelemetryServer) stopHealthCheckWatcher() {
-	if s.healthCheckWaiter != nil {
-		s.healthCheckWaiter.stop()
+	s.mutex.RLock()
+	waiter := s.healthCheckWaiter
+	s.mutex.RUnlock()
+
+	if waiter != nil {
+		waiter.stop()
 	}
 }
 
+// ExtracResourceByKey is the original, misspelled name of ExtractResourceByKey.
+//
+// Deprecated: Use ExtractResourceByKey, or ExtractResourceByKeyOK to choose the fallback.
 func ExtracResourceByKey(key attribute.Key, resource *resource.Resource) (result string) {
-	result = fmt.Sprintf("unknown-%s", key)
+	return ExtractResourceByKey(key, resource)
+}
+
+// ExtractResourceByKey returns the resource attribute for key as a string,
+// or "unknown-<key>" (e.g. "unknown-service.version") when it is missing.
+func ExtractResourceByKey(key attribute.Key, resource *resource.Resource) string {
+	result, ok := ExtractResourceByKeyOK(key, resource)
+	if !ok {
+		return resourceFallback(key, "")
+	}
+
+	return result
+}
+
+// ExtractResourceByKeyOK returns the resource attribute for key as a string and whether
+// it was present, so callers can pick their own fallback. A nil resource has no attributes.
+func ExtractResourceByKeyOK(key attribute.Key, resource *resource.Resource) (string, bool) {
 	if resource == nil {
-		return
+		return "", false
 	}
 
 	resourceValue, ok := resource.Set().Value(key)
 	if !ok {
-		return
+		return "", false
 	}
 
-	return resourceValue.AsString()
+	return resourceValue.AsString(), true
+}
+
+func resourceFallback(key attribute.Key, fallback string) string {
+	if fallback != "" {
+		return fallback
+	}
+	return fmt.Sprintf("unknown-%s", key)
 }
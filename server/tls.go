@@ -0,0 +1,99 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/domesama/doakes/config"
+
+	internalhttp "github.com/domesama/doakes/http"
+)
+
+// loadTLSConfig builds the internal port's TLS configuration, or nil when TLS is not
+// configured. With a client CA file, connections without a client certificate signed
+// by one of its CAs fail the handshake.
+func loadTLSConfig(serverConfig config.TelemetryServerConfig) (*tls.Config, error) {
+	if serverConfig.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(serverConfig.TLSCertFile, serverConfig.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if serverConfig.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(serverConfig.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", serverConfig.ClientCAFile)
+		}
+
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// GetRunningHealthAddress returns the address the plain HTTP health listener is listening on.
+// Returns empty string if HealthListenAddress is not configured or the server hasn't started yet.
+func (s *TelemetryServer) GetRunningHealthAddress() string {
+	if s.healthServer == nil {
+		return ""
+	}
+	return s.healthServer.ActualAddress()
+}
+
+func (s *TelemetryServer) listenHealthServer() error {
+	if s.healthServer == nil {
+		return nil
+	}
+
+	return s.healthServer.Listen(s.config.HealthListenAddress)
+}
+
+func (s *TelemetryServer) serveHealthServer() {
+	if s.healthServer == nil {
+		return
+	}
+
+	go func() {
+		err := s.healthServer.Serve()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Health listener failed", "error", err)
+		}
+	}()
+}
+
+// newHealthServer creates the plain HTTP health listener, or nil unless configured.
+func newHealthServer(
+	serverConfig config.TelemetryServerConfig,
+	routerConfig internalhttp.RouterConfig,
+) *internalhttp.Server {
+	if serverConfig.HealthListenAddress == "" {
+		return nil
+	}
+
+	return internalhttp.NewServer(
+		internalhttp.NewHealthRouter(routerConfig),
+		internalhttp.ServerConfig{
+			MaxHeaderBytes: serverConfig.MaxHeaderBytes,
+			Network:        serverConfig.ListenNetwork,
+		},
+	)
+}
@@ -0,0 +1,35 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusClient "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// FlushableProvider is implemented by *metrics.Provider. It is an interface so that
+// testutil does not import metrics, whose own tests use this package.
+type FlushableProvider interface {
+	ForceFlush(ctx context.Context) error
+	Gatherer() prometheus.Gatherer
+}
+
+// FlushAndGather forces the provider to flush and returns the metric families it would export.
+// Use it instead of sleeping until a periodic reader fires.
+func FlushAndGather(t *testing.T, provider FlushableProvider) *Metrics {
+	assert.NoError(t, provider.ForceFlush(context.Background()))
+
+	gathered, err := provider.Gatherer().Gather()
+	assert.NoError(t, err)
+
+	families := make(map[string]*prometheusClient.MetricFamily, len(gathered))
+	for _, family := range gathered {
+		families[family.GetName()] = family
+	}
+
+	return &Metrics{
+		families: families,
+	}
+}
@@ -0,0 +1,42 @@
+// Package healthchecktest provides health check doubles for tests of code that registers checks.
+package healthchecktest
+
+import "sync"
+
+// Check is a health check whose result tests flip at runtime, e.g. to simulate a
+// dependency going down. It passes until SetError is called with a non-nil error.
+type Check struct {
+	mutex sync.Mutex
+	err   error
+	calls int
+}
+
+// Controllable returns a passing Check. Register its Run method as the CheckFunction.
+func Controllable() *Check {
+	return &Check{}
+}
+
+// Run returns the error last passed to SetError. It is a healthcheck.CheckFunction.
+func (c *Check) Run() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.calls++
+	return c.err
+}
+
+// SetError makes subsequent runs return err; nil makes the check pass again.
+func (c *Check) SetError(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.err = err
+}
+
+// Calls returns how many times the check has run.
+func (c *Check) Calls() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.calls
+}
@@ -0,0 +1,35 @@
+package healthchecktest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/domesama/doakes/healthcheck"
+	"github.com/domesama/doakes/testutil/healthchecktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControllable(t *testing.T) {
+	check := healthchecktest.Controllable()
+
+	handler := healthcheck.NewHandler("test-service")
+	handler.RegisterCheck("database", check.Run)
+	handler.Enable()
+
+	status := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/_hc", nil))
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, status())
+
+	check.SetError(errors.New("connection refused"))
+	assert.Equal(t, http.StatusServiceUnavailable, status())
+
+	check.SetError(nil)
+	assert.Equal(t, http.StatusOK, status())
+	assert.Equal(t, 3, check.Calls())
+}
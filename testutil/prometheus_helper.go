@@ -2,21 +2,26 @@ package testutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"regexp"
 	"testing"
 
 	prometheusClient "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
-	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // PrometheusHelper helps test Prometheus metrics endpoints.
 type PrometheusHelper struct {
 	httpClient http.Client
 	port       int
-	parser     expfmt.TextParser
+	// handler, when set, is called directly instead of going over TCP
+	handler http.Handler
 }
 
 // NewPrometheusHelper creates a helper for testing Prometheus metrics.
@@ -24,17 +29,33 @@ func NewPrometheusHelper(port int) *PrometheusHelper {
 	return &PrometheusHelper{
 		httpClient: http.Client{},
 		port:       port,
-		parser:     expfmt.NewTextParser(model.UTF8Validation),
 	}
 }
 
+// NewInProcessHelper creates a helper that scrapes the given handler directly via ServeHTTP,
+// without binding a port. The handler can be the full router or a metrics handler
+// such as metrics.Provider.HTTPHandler(); requests are sent to /metrics.
+func NewInProcessHelper(handler http.Handler) *PrometheusHelper {
+	return &PrometheusHelper{
+		handler: handler,
+	}
+}
+
+// scrapeFormat is requested so exemplars survive parsing; the text format drops them.
+var scrapeFormat = expfmt.NewFormat(expfmt.TypeProtoDelim)
+
 // ParseMetrics fetches and parses metrics from the /metrics endpoint.
 func (h *PrometheusHelper) ParseMetrics(t *testing.T) *Metrics {
+	if h.handler != nil {
+		return h.parseInProcess(t)
+	}
+
 	ctx := context.Background()
 	url := fmt.Sprintf("http://localhost:%d/metrics", h.port)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	assert.NoError(t, err)
+	req.Header.Set("Accept", string(scrapeFormat))
 
 	resp, err := h.httpClient.Do(req)
 	assert.NoError(t, err)
@@ -44,8 +65,36 @@ func (h *PrometheusHelper) ParseMetrics(t *testing.T) *Metrics {
 		_ = resp.Body.Close()
 	}()
 
-	metricFamilies, err := h.parser.TextToMetricFamilies(resp.Body)
-	assert.NoError(t, err)
+	return h.parse(t, resp.Body, resp.Header)
+}
+
+func (h *PrometheusHelper) parseInProcess(t *testing.T) *Metrics {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", string(scrapeFormat))
+	recorder := httptest.NewRecorder()
+
+	h.handler.ServeHTTP(recorder, req)
+	assert.Equal(t, 200, recorder.Code)
+
+	return h.parse(t, recorder.Body, recorder.Header())
+}
+
+// parse decodes body in the format named by its Content-Type.
+func (h *PrometheusHelper) parse(t *testing.T, body io.Reader, header http.Header) *Metrics {
+	decoder := expfmt.NewDecoder(body, expfmt.ResponseFormat(header))
+
+	metricFamilies := make(map[string]*prometheusClient.MetricFamily)
+	for {
+		family := &prometheusClient.MetricFamily{}
+		err := decoder.Decode(family)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+		metricFamilies[family.GetName()] = family
+	}
 
 	return &Metrics{
 		families: metricFamilies,
@@ -57,6 +106,11 @@ type Metrics struct {
 	families map[string]*prometheusClient.MetricFamily
 }
 
+// Families returns the parsed metric families keyed by name.
+func (m *Metrics) Families() map[string]*prometheusClient.MetricFamily {
+	return m.families
+}
+
 // Get returns all metrics matching the name and labels.
 func (m *Metrics) Get(name string, labels map[string]string) []*prometheusClient.Metric {
 	family, ok := m.families[name]
@@ -100,6 +154,43 @@ func (m *Metrics) AssertNoMetric(t *testing.T, name string, labels map[string]st
 	assert.Empty(t, metrics, "expected no metric %s %v", name, labels)
 }
 
+// AssertMetricDropped records into a counter named name on meter, e.g. one listed in
+// MetricsConfig.DisabledInstruments, then scrapes helper and asserts nothing was exported
+// for it. meter must come from the provider helper scrapes, e.g. Provider.GetMeter(). A
+// control counter recorded alongside must show up, so a helper scraping another provider
+// fails instead of passing vacuously.
+func AssertMetricDropped(t *testing.T, meter metric.Meter, helper *PrometheusHelper, name string) {
+	t.Helper()
+
+	counter, err := meter.Float64Counter(name)
+	if !assert.NoError(t, err, "creating counter %s", name) {
+		return
+	}
+	control, err := meter.Float64Counter(droppedMetricControl)
+	if !assert.NoError(t, err, "creating counter %s", droppedMetricControl) {
+		return
+	}
+	counter.Add(context.Background(), 1)
+	control.Add(context.Background(), 1)
+
+	scraped := helper.ParseMetrics(t)
+	assert.NotEmpty(
+		t, scraped.Get(droppedMetricControl+"_total", nil),
+		"control counter %s not exported; does helper scrape meter's provider?", droppedMetricControl,
+	)
+
+	exposedName := invalidMetricNameChars.ReplaceAllString(name, "_")
+	for _, candidate := range []string{name, exposedName, exposedName + "_total"} {
+		scraped.AssertNoMetric(t, candidate, nil)
+	}
+}
+
+// droppedMetricControl is the counter AssertMetricDropped expects to be exported.
+const droppedMetricControl = "testutil_assert_metric_dropped_control"
+
+// invalidMetricNameChars matches what the exporter escapes in legacy metric names.
+var invalidMetricNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
 // AssertCounter asserts a counter metric has the expected value.
 func (m *Metrics) AssertCounter(t *testing.T, name string, labels map[string]string, expected float64) {
 	currentMetric := m.GetSingle(t, name, labels)
@@ -111,6 +202,17 @@ func (m *Metrics) AssertCounter(t *testing.T, name string, labels map[string]str
 	assert.InDelta(t, expected, actual, 0.0000001, "counter %s %v", name, labels)
 }
 
+// AssertGauge asserts a gauge metric has the expected value.
+func (m *Metrics) AssertGauge(t *testing.T, name string, labels map[string]string, expected float64) {
+	currentMetric := m.GetSingle(t, name, labels)
+	if !assert.NotNil(t, currentMetric, "currentMetric %s %v not found", name, labels) {
+		return
+	}
+
+	actual := currentMetric.Gauge.GetValue()
+	assert.InDelta(t, expected, actual, 0.0000001, "gauge %s %v", name, labels)
+}
+
 // AssertHistogramCount asserts a histogram's sample count.
 func (m *Metrics) AssertHistogramCount(t *testing.T, name string, labels map[string]string, expected uint64) {
 	metric := m.GetSingle(t, name, labels)
@@ -122,6 +224,24 @@ func (m *Metrics) AssertHistogramCount(t *testing.T, name string, labels map[str
 	assert.Equal(t, expected, actual, "histogram count %s %v", name, labels)
 }
 
+// AssertHistogramExemplar asserts that a bucket of the histogram carries an exemplar with
+// all of exemplarLabels, e.g. {"trace_id": spanContext.TraceID().String()}.
+func (m *Metrics) AssertHistogramExemplar(t *testing.T, name string, labels map[string]string,
+	exemplarLabels map[string]string) {
+	metric := m.GetSingle(t, name, labels)
+	if !assert.NotNil(t, metric, "metric %s %v not found", name, labels) {
+		return
+	}
+
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if exemplar := bucket.GetExemplar(); exemplar != nil && hasAllPairs(exemplar.GetLabel(), exemplarLabels) {
+			return
+		}
+	}
+
+	assert.Fail(t, "exemplar not found", "histogram %s %v has no exemplar with %v", name, labels, exemplarLabels)
+}
+
 // AssertMetricExists asserts that a metric exists with the expected type.
 func (m *Metrics) AssertMetricExists(t *testing.T, name string, labels map[string]string,
 	expectedType prometheusClient.MetricType) {
@@ -171,9 +291,13 @@ func AssertHistogramIncrease(t *testing.T, before, after *Metrics, name string,
 }
 
 func hasAllLabels(metric *prometheusClient.Metric, selectedLabels map[string]string) bool {
+	return hasAllPairs(metric.GetLabel(), selectedLabels)
+}
+
+func hasAllPairs(labels []*prometheusClient.LabelPair, selectedLabels map[string]string) bool {
 	labelsByName := make(map[string]string)
 
-	for _, label := range metric.Label {
+	for _, label := range labels {
 		labelsByName[label.GetName()] = label.GetValue()
 	}
 